@@ -0,0 +1,203 @@
+package actions
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+)
+
+// load balancers deliver a log file every 5 minutes, holding the requests since the previous one
+var accessLogDeliveryInterval = 5 * time.Minute
+var maxAccessLogLineBytes = 1024 * 1024
+
+// the positions of the fields in an access log entry
+var accessLogTimeField = 1
+var accessLogStatusField = 8
+var accessLogRequestField = 12
+
+// AlbAccessLogs downloads the load balancer access logs of a time window and summarizes the requests by status code and
+// host.
+type AlbAccessLogs struct {
+	Aws    *Aws
+	Bucket string
+	Prefix string
+	Window *TimeWindow
+}
+
+type accessLogSummary struct {
+	requests     int
+	statusCounts map[string]int
+	hostCounts   map[string]int
+	skippedLines int
+}
+
+func (logs *AlbAccessLogs) Summarize() {
+	summary := &accessLogSummary{statusCounts: map[string]int{}, hostCounts: map[string]int{}}
+	s3Service := logs.Aws.S3()
+
+	for _, key := range logs.logKeys(s3Service) {
+		logs.summarizeLogFile(s3Service, key, summary)
+	}
+
+	summary.print(logs.Window)
+}
+
+// logKeys are the log files that may hold requests in the window. The files are stored under the day they were
+// delivered, so the days of the window are listed and the files delivered within it are kept.
+func (logs *AlbAccessLogs) logKeys(s3Service *s3.S3) []string {
+	accountId := logs.accountId()
+	lastDelivery := logs.Window.End.Add(accessLogDeliveryInterval)
+
+	var keys []string
+	firstDay := logs.Window.Start.UTC().Truncate(24 * time.Hour)
+	for day := firstDay; !day.After(lastDelivery); day = day.Add(24 * time.Hour) {
+		prefix := fmt.Sprintf(
+			"%s/AWSLogs/%s/elasticloadbalancing/%s/%s/",
+			logs.Prefix, accountId, logs.Aws.Region, day.Format("2006/01/02"),
+		)
+
+		err := s3Service.ListObjectsV2Pages(
+			&s3.ListObjectsV2Input{Bucket: &logs.Bucket, Prefix: &prefix},
+			func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+				for _, object := range page.Contents {
+					if !object.LastModified.Before(logs.Window.Start) && !object.LastModified.After(lastDelivery) {
+						keys = append(keys, *object.Key)
+					}
+				}
+				return true
+			},
+		)
+		checkError(err)
+	}
+
+	SugaredLogger().Infof("Found %d access log files in bucket '%s'", len(keys), logs.Bucket)
+	return keys
+}
+
+func (logs *AlbAccessLogs) accountId() string {
+	return *(&AwsCall{
+		Action: "Looking up the account the access logs are delivered for",
+		Callable: func() (interface{}, error) {
+			return logs.Aws.Sts().GetCallerIdentity(&sts.GetCallerIdentityInput{})
+		},
+	}).Output().(*sts.GetCallerIdentityOutput).Account
+}
+
+func (logs *AlbAccessLogs) summarizeLogFile(s3Service *s3.S3, key string, summary *accessLogSummary) {
+	object := (&AwsCall{
+		Action: fmt.Sprintf("Downloading access log file '%s'", key),
+		Callable: func() (interface{}, error) {
+			return s3Service.GetObject(&s3.GetObjectInput{Bucket: &logs.Bucket, Key: &key})
+		},
+	}).Output().(*s3.GetObjectOutput)
+	defer object.Body.Close()
+
+	reader, err := gzip.NewReader(object.Body)
+	checkError(err)
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAccessLogLineBytes)
+	for scanner.Scan() {
+		summary.add(logs.Window, scanner.Text())
+	}
+	checkError(scanner.Err())
+}
+
+// add counts the entry if it falls within the window. Entries that cannot be parsed are counted as skipped.
+func (summary *accessLogSummary) add(window *TimeWindow, line string) {
+	fields := accessLogFields(line)
+	if len(fields) <= accessLogRequestField {
+		summary.skippedLines++
+		return
+	}
+
+	requestTime, err := time.Parse(time.RFC3339Nano, fields[accessLogTimeField])
+	if err != nil {
+		summary.skippedLines++
+		return
+	}
+	if !window.Contains(requestTime) {
+		return
+	}
+
+	summary.requests++
+	summary.statusCounts[fields[accessLogStatusField]]++
+	summary.hostCounts[requestHost(fields[accessLogRequestField])]++
+}
+
+func (summary *accessLogSummary) print(window *TimeWindow) {
+	SugaredLogger().Infow(
+		"Access log summary",
+		"Start", window.Start.Format(time.RFC3339),
+		"End", window.End.Format(time.RFC3339),
+		"Requests", summary.requests,
+		"Skipped lines", summary.skippedLines,
+	)
+
+	for _, status := range sortedByCount(summary.statusCounts) {
+		SugaredLogger().Infow("Requests by status code", "Status", status, "Count", summary.statusCounts[status])
+	}
+	for _, host := range sortedByCount(summary.hostCounts) {
+		SugaredLogger().Infow("Requests by host", "Host", host, "Count", summary.hostCounts[host])
+	}
+}
+
+// accessLogFields splits an entry on spaces, keeping the quoted fields e.g. the request and user agent whole.
+func accessLogFields(line string) []string {
+	var fields []string
+	var field bytes.Buffer
+	quoted := false
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(r)
+		}
+	}
+
+	return append(fields, field.String())
+}
+
+// requestHost is the host of a request field e.g. 'GET https://blog.example.com:443/ HTTP/1.1'.
+func requestHost(request string) string {
+	parts := strings.Fields(request)
+	if len(parts) < 2 {
+		return "-"
+	}
+
+	requestUrl, err := url.Parse(parts[1])
+	if err != nil || requestUrl.Hostname() == "" {
+		return "-"
+	}
+	return requestUrl.Hostname()
+}
+
+// sortedByCount are the keys with the highest counts first, ties being sorted by key.
+func sortedByCount(counts map[string]int) []string {
+	var keys []string
+	for key := range counts {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
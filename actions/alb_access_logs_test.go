@@ -0,0 +1,74 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+)
+
+var accessLogLine = `https 2018-07-02T22:23:00.186641Z app/my-loadbalancer/50dc6c495c0c9188 192.168.131.39:2817 ` +
+	`10.0.0.1:80 0.086 0.048 0.037 200 200 0 57 "GET https://www.example.com:443/ HTTP/1.1" "curl/7.46.0" ` +
+	`ECDHE-RSA-AES128-GCM-SHA256 TLSv1.2 ` +
+	`arn:aws:elasticloadbalancing:us-east-2:123456789012:targetgroup/my-targets/73e2d6bc24d8a067 ` +
+	`"Root=1-58337281-1d84f3d73c47ec4e58577259" "www.example.com" "-" 0 2018-07-02T22:22:48.364000Z "forward" "-" "-"`
+
+func TestAccessLogFields(t *testing.T) {
+	for _, test := range []struct {
+		line     string
+		expected []string
+	}{
+		{"", []string{""}},
+		{"a b c", []string{"a", "b", "c"}},
+		{`a "b c" d`, []string{"a", "b c", "d"}},
+		{`a "" d`, []string{"a", "", "d"}},
+		{`"a b" "c d"`, []string{"a b", "c d"}},
+		{`a "b c`, []string{"a", "b c"}},
+	} {
+		if fields := accessLogFields(test.line); !reflect.DeepEqual(fields, test.expected) {
+			t.Errorf("%s: expected %q, got %q", test.line, test.expected, fields)
+		}
+	}
+}
+
+func TestAccessLogFieldPositions(t *testing.T) {
+	fields := accessLogFields(accessLogLine)
+
+	for _, test := range []struct {
+		field    string
+		position int
+		expected string
+	}{
+		{"time", accessLogTimeField, "2018-07-02T22:23:00.186641Z"},
+		{"status", accessLogStatusField, "200"},
+		{"request", accessLogRequestField, "GET https://www.example.com:443/ HTTP/1.1"},
+	} {
+		if fields[test.position] != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.field, test.expected, fields[test.position])
+		}
+	}
+}
+
+func TestRequestHost(t *testing.T) {
+	for _, test := range []struct {
+		request  string
+		expected string
+	}{
+		{"GET https://www.example.com:443/ HTTP/1.1", "www.example.com"},
+		{"POST http://blog.example.com:80/wp-login.php HTTP/1.1", "blog.example.com"},
+		{"- http://10.0.0.1:80- -", "-"},
+		{"GET / HTTP/1.1", "-"},
+		{"-", "-"},
+	} {
+		if host := requestHost(test.request); host != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.request, test.expected, host)
+		}
+	}
+}
+
+func TestSortedByCount(t *testing.T) {
+	counts := map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}
+	expected := []string{"c", "a", "b", "d"}
+
+	if keys := sortedByCount(counts); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %s, got %s", expected, keys)
+	}
+}
@@ -2,18 +2,24 @@ package actions
 
 import (
 	"fmt"
+	"sort"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/acm"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53domains"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
 )
 
 var regionFilterKey = "region-name"
+var vpcIdFilterKey = "vpc-id"
 
 // Aws handles creating AWS services. It ensures that all of the services are generated using the same profile and
 // region.
@@ -42,6 +48,14 @@ func (a *Aws) Route53Domains() *route53domains.Route53Domains {
 	return route53domains.New(a.session())
 }
 
+func (a *Aws) S3() *s3.S3 {
+	return s3.New(a.session())
+}
+
+func (a *Aws) Sts() *sts.STS {
+	return sts.New(a.session())
+}
+
 func (a *Aws) Azs() []*ec2.AvailabilityZone {
 	describeOutput, err := a.Ec2Service().DescribeAvailabilityZones(&ec2.DescribeAvailabilityZonesInput{
 		Filters: []*ec2.Filter{
@@ -58,6 +72,50 @@ func (a *Aws) Azs() []*ec2.AvailabilityZone {
 	return describeOutput.AvailabilityZones
 }
 
+// SubnetIds are the ids of the subnets in the VPC that have all of the tags, sorted so that the same subnets always
+// come back in the same order.
+func (a *Aws) SubnetIds(vpcId string, tags map[string]string) []string {
+	filters := []*ec2.Filter{
+		{
+			Name:   &vpcIdFilterKey,
+			Values: []*string{&vpcId},
+		},
+	}
+	for key, value := range tags {
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String(fmt.Sprintf("tag:%s", key)),
+			Values: []*string{aws.String(value)},
+		})
+	}
+
+	describeOutput, err := a.Ec2Service().DescribeSubnets(&ec2.DescribeSubnetsInput{Filters: filters})
+	checkError(err)
+
+	var subnetIds []string
+	for _, subnet := range describeOutput.Subnets {
+		subnetIds = append(subnetIds, *subnet.SubnetId)
+	}
+	if len(subnetIds) == 0 {
+		panic(fmt.Sprintf("No subnets in VPC '%s' have the tags %v", vpcId, tags))
+	}
+	sort.Strings(subnetIds)
+
+	return subnetIds
+}
+
+// SubnetAzs are the availability zones of the subnets, keyed by the subnet id.
+func (a *Aws) SubnetAzs(subnetIds []string) map[string]string {
+	describeOutput, err := a.Ec2Service().DescribeSubnets(&ec2.DescribeSubnetsInput{SubnetIds: aws.StringSlice(subnetIds)})
+	checkError(err)
+
+	subnetAzs := map[string]string{}
+	for _, subnet := range describeOutput.Subnets {
+		subnetAzs[*subnet.SubnetId] = *subnet.AvailabilityZone
+	}
+
+	return subnetAzs
+}
+
 func (a *Aws) session() *session.Session {
 	SugaredLogger().Infof("Using profile '%s' to talk to an AWS service", a.Profile)
 	region := a.Region.String()
@@ -72,9 +130,33 @@ func (a *Aws) session() *session.Session {
 	})
 	checkError(err)
 
+	// the request id is known once the response metadata has been unmarshalled, whether or not the call succeeded
+	sess.Handlers.Unmarshal.PushBack(logRequestId)
+	sess.Handlers.UnmarshalError.PushBack(logFailedRequestId)
+
 	return sess
 }
 
+// logRequestId logs successful calls at debug level, as every call makes one
+func logRequestId(r *request.Request) {
+	SugaredLogger().Debugw(
+		"AWS request",
+		"Service", r.ClientInfo.ServiceName,
+		"Operation", r.Operation.Name,
+		"RequestId", r.RequestID,
+	)
+}
+
+func logFailedRequestId(r *request.Request) {
+	SugaredLogger().Warnw(
+		"AWS request failed",
+		"Service", r.ClientInfo.ServiceName,
+		"Operation", r.Operation.Name,
+		"RequestId", r.RequestID,
+		"Error", r.Error,
+	)
+}
+
 func checkError(e error) {
 	if e != nil {
 		panic(e)
@@ -89,21 +171,32 @@ type AwsCall struct {
 }
 
 func (awsCall *AwsCall) Output() interface{} {
-	SugaredLogger().Debugf("Performing action: '%s'", awsCall.Action)
+	SugaredLogger().Debugf("Performing action: '%s'", awsCall.description())
 	output, err := awsCall.Callable()
 	awsCall.check(output, err)
 	return output
 }
 
+// description is the action along with the run id, so that a failure can be matched with the rest of the run's logs.
+func (awsCall *AwsCall) description() string {
+	return fmt.Sprintf("%s [run %s]", awsCall.Action, RunId())
+}
+
 func (awsCall *AwsCall) check(output interface{}, err error) {
 	if err != nil {
+		requestId := ""
+		if requestFailure, ok := err.(awserr.RequestFailure); ok {
+			requestId = requestFailure.RequestID()
+		}
+
 		panic(fmt.Sprintf(
-			"Error occurred performing action '%s'\n  Output: '%s'\n  Error: '%s'\n",
-			awsCall.Action,
+			"Error occurred performing action '%s'\n  Request ID: '%s'\n  Output: '%s'\n  Error: '%s'\n",
+			awsCall.description(),
+			requestId,
 			output,
 			err,
 		))
 	} else {
-		SugaredLogger().Debugf("AWS Call SUCCESS. Action: '%s'. Output: '%s'", awsCall.Action, output)
+		SugaredLogger().Debugf("AWS Call SUCCESS. Action: '%s'. Output: '%s'", awsCall.description(), output)
 	}
 }
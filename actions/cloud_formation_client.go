@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"strings"
+	"fmt"
 	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
 )
 
@@ -40,6 +41,7 @@ func (client *CloudFormationClient) CreateCloudFormationStack(
 		Parameters:   parameters,
 		TemplateBody: templateString(template),
 		Capabilities: []*string{&iamCapability},
+		Tags:         stackInfo.StackTags(),
 	}
 
 	(&AwsCall{
@@ -52,14 +54,27 @@ func (client *CloudFormationClient) CreateCloudFormationStack(
 	models.SugaredLogger().Infof("Stack creation in progress: %s", *stackInfo.StackName())
 }
 
+// UpdateCloudFormationStack keeps the previous values of the template's parameters that are not given, such as the
+// maintenance mode of the sites, so that updating the stack does not reset them to their defaults.
 func (client *CloudFormationClient) UpdateCloudFormationStack(
 	stackInfo *models.StackInfo, template *Template, parameters []*cloudformation.Parameter,
 ) {
+	given := map[string]bool{}
+	for _, parameter := range parameters {
+		given[*parameter.ParameterKey] = true
+	}
+	for key := range client.StackParameters(stackInfo) {
+		if _, declared := template.Parameters[key]; declared && !given[key] {
+			parameters = append(parameters, previousParameter(key))
+		}
+	}
+
 	input := &cloudformation.UpdateStackInput{
 		StackName:    stackInfo.StackName(),
 		Parameters:   parameters,
 		TemplateBody: templateString(template),
 		Capabilities: []*string{&iamCapability},
+		Tags:         stackInfo.StackTags(),
 	}
 
 	(&AwsCall{
@@ -72,6 +87,75 @@ func (client *CloudFormationClient) UpdateCloudFormationStack(
 	models.SugaredLogger().Infof("Stack update in progress: %s", *stackInfo.StackName())
 }
 
+// UpdateStackParameter sets one parameter of the stack, keeping its template and the values of the other parameters.
+func (client *CloudFormationClient) UpdateStackParameter(stackInfo *models.StackInfo, key string, value string) {
+	previous := client.StackParameters(stackInfo)
+	if _, exists := previous[key]; !exists {
+		panic(fmt.Sprintf(
+			"Stack '%s' does not have the parameter '%s'. Update the stack first", *stackInfo.StackName(), key,
+		))
+	}
+
+	parameters := []*cloudformation.Parameter{{ParameterKey: &key, ParameterValue: &value}}
+	for previousKey := range previous {
+		if previousKey != key {
+			parameters = append(parameters, previousParameter(previousKey))
+		}
+	}
+
+	usePreviousTemplate := true
+	(&AwsCall{
+		Action: fmt.Sprintf("Setting parameter '%s' of CloudFormation stack", key),
+		Callable: func() (interface{}, error) {
+			return client.CloudFormationService.UpdateStack(&cloudformation.UpdateStackInput{
+				StackName:           stackInfo.StackName(),
+				Parameters:          parameters,
+				UsePreviousTemplate: &usePreviousTemplate,
+				Capabilities:        []*string{&iamCapability},
+				Tags:                stackInfo.StackTags(),
+			})
+		},
+	}).Output()
+
+	models.SugaredLogger().Infof("Stack update in progress: %s", *stackInfo.StackName())
+}
+
+// StackParameters are the current values of the stack's parameters, keyed by name.
+func (client *CloudFormationClient) StackParameters(stackInfo *models.StackInfo) map[string]string {
+	parameters := map[string]string{}
+	stack := client.describeStack(stackInfo, "Looking up parameters of CloudFormation stack")
+	for _, parameter := range stack.Parameters {
+		parameters[*parameter.ParameterKey] = *parameter.ParameterValue
+	}
+	return parameters
+}
+
+// StackStatus is the status of the stack's last operation, e.g. UPDATE_COMPLETE.
+func (client *CloudFormationClient) StackStatus(stackInfo *models.StackInfo) string {
+	return *client.describeStack(stackInfo, "Looking up status of CloudFormation stack").StackStatus
+}
+
+func (client *CloudFormationClient) describeStack(stackInfo *models.StackInfo, action string) *cloudformation.Stack {
+	stacks := (&AwsCall{
+		Action: action,
+		Callable: func() (interface{}, error) {
+			return client.CloudFormationService.DescribeStacks(&cloudformation.DescribeStacksInput{
+				StackName: stackInfo.StackName(),
+			})
+		},
+	}).Output().(*cloudformation.DescribeStacksOutput).Stacks
+
+	if len(stacks) == 0 {
+		panic(fmt.Sprintf("Stack '%s' does not exist", *stackInfo.StackName()))
+	}
+	return stacks[0]
+}
+
+func previousParameter(key string) *cloudformation.Parameter {
+	usePreviousValue := true
+	return &cloudformation.Parameter{ParameterKey: &key, UsePreviousValue: &usePreviousValue}
+}
+
 func (client *CloudFormationClient) DescribeCloudFormationStack(stackInfo *models.StackInfo) {
 	output := (&AwsCall{
 		Action: "Describe CloudFormation stack",
@@ -85,6 +169,28 @@ func (client *CloudFormationClient) DescribeCloudFormationStack(stackInfo *model
 	models.SugaredLogger().Infof("%s", output)
 }
 
+// StackOutput is the value of one of the stack's outputs. It panics if the stack does not have the output.
+func (client *CloudFormationClient) StackOutput(stackInfo *models.StackInfo, outputKey string) string {
+	stacks := (&AwsCall{
+		Action: fmt.Sprintf("Looking up output '%s' of CloudFormation stack", outputKey),
+		Callable: func() (interface{}, error) {
+			return client.CloudFormationService.DescribeStacks(&cloudformation.DescribeStacksInput{
+				StackName: stackInfo.StackName(),
+			})
+		},
+	}).Output().(*cloudformation.DescribeStacksOutput).Stacks
+
+	for _, stack := range stacks {
+		for _, output := range stack.Outputs {
+			if *output.OutputKey == outputKey {
+				return *output.OutputValue
+			}
+		}
+	}
+
+	panic(fmt.Sprintf("Stack '%s' does not have the output '%s'", *stackInfo.StackName(), outputKey))
+}
+
 func (client *CloudFormationClient) DeleteCloudFormationStack(stackInfo *models.StackInfo) {
 	(&AwsCall{
 		Action: "Delete CloudFormation stack",
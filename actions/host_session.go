@@ -0,0 +1,69 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+)
+
+// instances launched by an auto scaling group created by CloudFormation are tagged with the name of the stack
+var stackNameInstanceFilterKey = "tag:aws:cloudformation:stack-name"
+var instanceStateFilterKey = "instance-state-name"
+
+// HostSession opens a Session Manager session to one of the hosts in a stage's cluster. The session is run by the AWS
+// CLI, which needs the Session Manager plugin installed.
+type HostSession struct {
+	Aws       *Aws
+	StackInfo *StackInfo
+}
+
+func (hs *HostSession) Start() {
+	instanceId := hs.instanceId()
+	SugaredLogger().Infof("Starting a session to host '%s' in stack '%s'", instanceId, *hs.StackInfo.StackName())
+
+	cmd := exec.Command(
+		"aws", "ssm", "start-session",
+		"--target", instanceId,
+		"--profile", hs.Aws.Profile,
+		"--region", hs.Aws.Region.String(),
+	)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		panic(fmt.Sprintf("Session to host '%s' failed: %s", instanceId, err))
+	}
+}
+
+// instanceId is the first running host in the stack.
+func (hs *HostSession) instanceId() string {
+	reservations := (&AwsCall{
+		Action: fmt.Sprintf("Finding the running hosts in stack '%s'", *hs.StackInfo.StackName()),
+		Callable: func() (interface{}, error) {
+			return hs.Aws.Ec2Service().DescribeInstances(&ec2.DescribeInstancesInput{
+				Filters: []*ec2.Filter{
+					{
+						Name:   &stackNameInstanceFilterKey,
+						Values: []*string{hs.StackInfo.StackName()},
+					},
+					{
+						Name:   &instanceStateFilterKey,
+						Values: []*string{aws.String(ec2.InstanceStateNameRunning)},
+					},
+				},
+			})
+		},
+	}).Output().(*ec2.DescribeInstancesOutput).Reservations
+
+	for _, reservation := range reservations {
+		for _, instance := range reservation.Instances {
+			return *instance.InstanceId
+		}
+	}
+
+	panic(fmt.Sprintf("Could not find any running hosts in stack '%s'", *hs.StackInfo.StackName()))
+}
@@ -0,0 +1,43 @@
+package actions
+
+import (
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+)
+
+var siteNotDeployed = "not deployed"
+var siteServing = "serving"
+var siteInMaintenance = "maintenance"
+
+// ServiceStatus reports the status of the service stack and whether each of its WordPress sites is serving or in
+// maintenance.
+type ServiceStatus struct {
+	Client    *CloudFormationClient
+	StackInfo *models.StackInfo
+	Sites     []*models.Site
+}
+
+func (status *ServiceStatus) Print() {
+	logger := models.SugaredLogger()
+	logger.Infow(
+		"Service stack", "Stack", *status.StackInfo.StackName(), "Status", status.Client.StackStatus(status.StackInfo),
+	)
+
+	parameters := status.Client.StackParameters(status.StackInfo)
+	for _, site := range status.Sites {
+		logger.Infow("WordPress site", "Site", site.Name, "Status", siteStatus(parameters, site))
+	}
+}
+
+// siteStatus tells the sites in maintenance apart by their maintenance parameter. Sites without the parameter have
+// not been added to the stack yet.
+func siteStatus(parameters map[string]string, site *models.Site) string {
+	mode, exists := parameters[models.MaintenanceParamName(site)]
+	switch {
+	case !exists:
+		return siteNotDeployed
+	case mode == models.MaintenanceOn:
+		return siteInMaintenance
+	default:
+		return siteServing
+	}
+}
@@ -119,3 +119,30 @@ func (sslCerts *SslCertificateRequest) updateHostedZone(record *acm.ResourceReco
 
 	SugaredLogger().Infof("Current status of DNS update is: '%s'", *changeOutput.ChangeInfo.Status)
 }
+
+// IssuedCertificateArn is the ARN of the issued certificate for the domain name, as requested by SslCertificateRequest.
+func IssuedCertificateArn(certManager *acm.ACM, domainName string) string {
+	allSubDomains := fmt.Sprintf("*.%s", domainName)
+	issuedStatus := acm.CertificateStatusIssued
+
+	var certArn string
+	err := certManager.ListCertificatesPages(
+		&acm.ListCertificatesInput{CertificateStatuses: []*string{&issuedStatus}},
+		func(page *acm.ListCertificatesOutput, lastPage bool) bool {
+			for _, summary := range page.CertificateSummaryList {
+				if *summary.DomainName == allSubDomains || *summary.DomainName == domainName {
+					certArn = *summary.CertificateArn
+					return false
+				}
+			}
+			return true
+		},
+	)
+	checkError(err)
+
+	if certArn == "" {
+		panic(fmt.Sprintf("Could not find an issued certificate for '%s'. Request one with setup-site-ssl", domainName))
+	}
+
+	return certArn
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"time"
 	"github.com/urfave/cli"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/actions"
@@ -20,13 +21,93 @@ func (cm *CliModels) CloudFormationClient() *CloudFormationClient {
 
 func (cm *CliModels) AlertSysConfig() *TemplateConfig {
 	config := TemplateConfig{
-		Region: cm.awsRegion(),
-		Stage:  StageFromString(StageCliOpt.Value(cm.Context)),
+		Region:  cm.awsRegion(),
+		Stage:   StageFromString(StageCliOpt.Value(cm.Context)),
+		Service: cm.ServiceConfig(),
 	}
 
 	return &config
 }
 
+func (cm *CliModels) ServiceConfig() *ServiceConfig {
+	if ConfigFileCliOpt.IsAbsent(cm.Context) {
+		return EmptyServiceConfig()
+	}
+
+	return ServiceConfigFromFile(ConfigFileCliOpt.Value(cm.Context))
+}
+
+// ExistingVpc is the VPC the stage is deployed into with any subnets that are configured by tag looked up, or nil if
+// the stack creates its own VPC.
+func (cm *CliModels) ExistingVpc() *ExistingVpc {
+	vpcConfig := cm.AlertSysConfig().StageConfig().ExistingVpc
+	if vpcConfig == nil {
+		return nil
+	}
+
+	vpc := &ExistingVpc{
+		VpcId:           vpcConfig.VpcId,
+		PublicSubnetIds: cm.subnetIds(vpcConfig.VpcId, vpcConfig.PublicSubnetIds, vpcConfig.PublicSubnetTags),
+	}
+	if vpcConfig.HasAppSubnets() {
+		vpc.AppSubnetIds = cm.subnetIds(vpcConfig.VpcId, vpcConfig.AppSubnetIds, vpcConfig.AppSubnetTags)
+	} else {
+		vpc.AppSubnetIds = vpc.PublicSubnetIds
+	}
+
+	return vpc
+}
+
+func (cm *CliModels) subnetIds(vpcId string, subnetIds []string, tags map[string]string) []string {
+	if len(subnetIds) > 0 {
+		return subnetIds
+	}
+
+	return cm.Aws().SubnetIds(vpcId, tags)
+}
+
+// SiteCertificateArns are the certificates of the sites with domains of their own, keyed by the site name. Sites that
+// do not name their certificate use the issued certificate for their domain.
+func (cm *CliModels) SiteCertificateArns(sites []*Site) map[string]string {
+	config := cm.AlertSysConfig()
+
+	certArns := map[string]string{}
+	for _, site := range sites {
+		siteConfig := config.SiteConfig(site)
+		if !siteConfig.HasOwnDomain() {
+			continue
+		}
+
+		if siteConfig.CertificateArn != "" {
+			certArns[site.Name] = siteConfig.CertificateArn
+		} else {
+			certArns[site.Name] = IssuedCertificateArn(cm.Aws().CertificateManager(), siteConfig.DomainName)
+		}
+	}
+
+	return certArns
+}
+
+// LogSettings are the logging settings from the configuration file, overridden by the command line options.
+func (cm *CliModels) LogSettings() *LogSettings {
+	settings := cm.ServiceConfig().Logging
+	settings.Level = LogLevelCliOpt.ValueOrDefault(cm.Context, settings.Level)
+	settings.Format = LogFormatCliOpt.ValueOrDefault(cm.Context, settings.Format)
+	settings.File = LogFileCliOpt.ValueOrDefault(cm.Context, settings.File)
+
+	return &settings
+}
+
+// LogWindow is the time window given by the start and end time options.
+func (cm *CliModels) LogWindow() *TimeWindow {
+	window, err := TimeWindowFromStrings(StartTimeCliOpt.Value(cm.Context), EndTimeCliOpt.Value(cm.Context), time.Now())
+	if err != nil {
+		panic(err)
+	}
+
+	return window
+}
+
 func (cm *CliModels) Aws() *Aws {
 	return &Aws{
 		Profile: cm.awsProfile(),
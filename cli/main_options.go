@@ -27,6 +27,30 @@ var RegionCliOpt = GlobalStringCliOption{&StringCliOptionImpl{
 	Usage:    fmt.Sprintf("Region to use. Default: %s. Choices: %s", DefaultRegion, []Region{UsEast1, UsWest2}),
 }}
 
+var ConfigFileCliOpt = GlobalStringCliOption{&StringCliOptionImpl{
+	LongOpt:  "config-file",
+	ShortOpt: "j",
+	Usage:    "JSON configuration file for the service e.g. owner and cost center tags. Optional",
+}}
+
+var LogLevelCliOpt = GlobalStringCliOption{&StringCliOptionImpl{
+	LongOpt:  "log-level",
+	ShortOpt: "g",
+	Usage:    "Log level e.g. debug, info, warn. Default: info, or debug if the DEBUG environment variable is set",
+}}
+
+var LogFormatCliOpt = GlobalStringCliOption{&StringCliOptionImpl{
+	LongOpt:  "log-format",
+	ShortOpt: "m",
+	Usage:    fmt.Sprintf("Log encoding. Choices: %s", []string{ConsoleLogFormat, JsonLogFormat}),
+}}
+
+var LogFileCliOpt = GlobalStringCliOption{&StringCliOptionImpl{
+	LongOpt:  "log-file",
+	ShortOpt: "u",
+	Usage:    "File to write the logs to in addition to stdout. Optional",
+}}
+
 // Command options - the short options can be reused for different commands
 var DbPasswordCliOpt = CommandStringCliOption{&StringCliOptionImpl{
 	LongOpt:  "db-password",
@@ -52,22 +76,10 @@ var SslArnCliOpt = CommandStringCliOption{&StringCliOptionImpl{
 	Usage:    "The AWS ARN of the SSL certificate created by AWS Certificate Manager",
 }}
 
-var ElbDomainNameCliOpt = CommandStringCliOption{&StringCliOptionImpl{
-	LongOpt:  "elb-domain-name",
-	ShortOpt: "e",
-	Usage:    "Domain name of the Elastic Load Balancer",
-}}
-
-var ElbHostedZoneCliOpt = CommandStringCliOption{&StringCliOptionImpl{
-	LongOpt:  "elb-hosted-zone",
-	ShortOpt: "n",
-	Usage:    "Hosted zone of the Elastic Load Balancer",
-}}
-
 var Ec2KeyNameCliOpt = CommandStringCliOption{&StringCliOptionImpl{
 	LongOpt:  "ec2-key-name",
 	ShortOpt: "k",
-	Usage:    "SSH key name for logging into the generated EC2 instances",
+	Usage:    "SSH key name for logging into the generated EC2 instances. Not needed for Session Manager stages",
 }}
 
 var WordPressSubDomainsOpt = CommandStringCliOption{&StringCliOptionImpl{
@@ -142,3 +154,15 @@ var OperationIdCliOpt = CommandStringCliOption{&StringCliOptionImpl{
 	ShortOpt: "i",
 	Usage:    "Operation ID obtained from registering a domain name",
 }}
+
+var StartTimeCliOpt = CommandStringCliOption{&StringCliOptionImpl{
+	LongOpt:  "start-time",
+	ShortOpt: "t",
+	Usage:    "Start of the time window as an RFC 3339 time e.g. 2006-01-02T15:04:05Z. Default: an hour before the end",
+}}
+
+var EndTimeCliOpt = CommandStringCliOption{&StringCliOptionImpl{
+	LongOpt:  "end-time",
+	ShortOpt: "e",
+	Usage:    "End of the time window as an RFC 3339 time e.g. 2006-01-02T15:04:05Z. Default: now",
+}}
@@ -5,12 +5,13 @@ import (
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/actions"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/constants"
 	. "github.com/crewjam/go-cloudformation"
 	"github.com/urfave/cli"
 	"os"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/acm"
-	"strings"
+	"time"
 )
 
 var actionSuccess error = nil
@@ -22,7 +23,17 @@ func main() {
 	app.Usage = "create and update the Colectiva Alert System Cloud Formation template and more"
 	app.Version = "0.0.1"
 
-	app.Flags = []cli.Flag{ProfileCliOpt.Flag(), StageCliOpt.Flag(), RegionCliOpt.Flag()}
+	app.Flags = []cli.Flag{
+		ProfileCliOpt.Flag(), StageCliOpt.Flag(), RegionCliOpt.Flag(), ConfigFileCliOpt.Flag(), LogLevelCliOpt.Flag(),
+		LogFormatCliOpt.Flag(), LogFileCliOpt.Flag(),
+	}
+	app.Before = func(c *cli.Context) error {
+		if err := ConfigureLogger((&CliModels{Context: c}).LogSettings()); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		return actionSuccess
+	}
 
 	app.Commands = []cli.Command{
 		{
@@ -116,6 +127,35 @@ func main() {
 				)
 			},
 		},
+		{
+			Name:  "setup-site-ssl",
+			Usage: "Request SSL certificates for the sites with domains of their own that do not name a certificate",
+			Flags: []cli.Flag{WordPressSubDomainsOpt.Flag()},
+			Action: func(c *cli.Context) error {
+				return runIfValidOptions(
+					c,
+					[]StringCliOption{&StageCliOpt, &WordPressSubDomainsOpt},
+					validateServiceOptions,
+					func() {
+						cliModels := CliModels{Context: c}
+						config := cliModels.AlertSysConfig()
+						for _, site := range wordPressSites(c) {
+							siteConfig := config.SiteConfig(site)
+							if !siteConfig.HasOwnDomain() || siteConfig.CertificateArn != "" {
+								continue
+							}
+
+							(&SslCertificateRequest{
+								CertManager:  cliModels.Aws().CertificateManager(),
+								Route53:      cliModels.Aws().Route53(),
+								DomainName:   siteConfig.DomainName,
+								HostedZoneId: siteConfig.HostedZoneId,
+							}).Execute()
+						}
+					},
+				)
+			},
+		},
 		{
 			Name:  "describe-ssl",
 			Usage: "Describe the SSL certificate",
@@ -155,13 +195,15 @@ func main() {
 				writeFlags:        []cli.Flag{StageCliOpt.Flag(), WordPressSubDomainsOpt.Flag()},
 				writeRequiredOpts: []StringCliOption{&StageCliOpt, &WordPressSubDomainsOpt},
 				createFlags: []cli.Flag{
-					DomainCliOpt.Flag(), DbPasswordCliOpt.Flag(), SslArnCliOpt.Flag(), Ec2KeyNameCliOpt.Flag(),
-					WordPressSubDomainsOpt.Flag(),
+					DomainCliOpt.Flag(), HostedZoneIdCliOpt.Flag(), DbPasswordCliOpt.Flag(), SslArnCliOpt.Flag(),
+					Ec2KeyNameCliOpt.Flag(), WordPressSubDomainsOpt.Flag(),
 				},
 				createRequiredOpts: []StringCliOption{
-					&StageCliOpt, &DbPasswordCliOpt, &DomainCliOpt, &SslArnCliOpt, &WordPressSubDomainsOpt,
-					&Ec2KeyNameCliOpt,
+					&StageCliOpt, &DbPasswordCliOpt, &DomainCliOpt, &HostedZoneIdCliOpt, &SslArnCliOpt,
+					&WordPressSubDomainsOpt,
 				},
+				validate:       validateServiceOptions,
+				createValidate: validateServiceCreateOptions,
 				stackInfo: func(context *cli.Context) *StackInfo {
 					return ServiceStackInfo((&CliModels{Context: context}).AlertSysConfig())
 				},
@@ -173,46 +215,107 @@ func main() {
 						Template:            t,
 						Config:              cliModels.AlertSysConfig(),
 						AZs:                 cliModels.Aws().Azs(),
-						WordPressSubDomains: strings.Split(WordPressSubDomainsOpt.Value(context), wordPressSeparator),
+						WordPressSites:      wordPressSites(context),
+						ExistingVpc:         cliModels.ExistingVpc(),
+						SiteCertificateArns: cliModels.SiteCertificateArns(wordPressSites(context)),
 					}).AddToTemplate()
 
 					return t
 				},
 				parameters: func(context *cli.Context) []*cloudformation.Parameter {
+					cliModels := CliModels{Context: context}
 					return (&ServiceParameters{
-						Config: (&CliModels{Context: context}).AlertSysConfig(),
+						Config:      cliModels.AlertSysConfig(),
+						ExistingVpc: cliModels.ExistingVpc(),
 					}).CloudFormationParameters(
 						DbPasswordCliOpt.Value(context),
 						DomainCliOpt.Value(context),
 						SslArnCliOpt.Value(context),
 						Ec2KeyNameCliOpt.Value(context),
+						HostedZoneIdCliOpt.Value(context),
 					)
 				},
 			}).SubCommands(),
 		},
 		{
-			Name:  "create-elb-alias",
-			Usage: "Create an alias from the domain name to the ELB public domain name",
-			Flags: []cli.Flag{
-				DomainCliOpt.Flag(), HostedZoneIdCliOpt.Flag(), ElbDomainNameCliOpt.Flag(), ElbHostedZoneCliOpt.Flag(),
-				WordPressSubDomainsOpt.Flag(),
-			},
+			Name:    "session",
+			Aliases: []string{"ssh"},
+			Usage:   "Open a Session Manager session to a host in the stage's cluster",
 			Action: func(c *cli.Context) error {
-				return runIfRequiredOptions(
+				return runIfValidOptions(
 					c,
-					[]StringCliOption{
-						&DomainCliOpt, &HostedZoneIdCliOpt, &ElbDomainNameCliOpt, &ElbHostedZoneCliOpt,
-						&WordPressSubDomainsOpt,
+					[]StringCliOption{&StageCliOpt},
+					validateSessionOptions,
+					func() {
+						cliModels := CliModels{Context: c}
+						(&HostSession{
+							Aws:       cliModels.Aws(),
+							StackInfo: ServiceStackInfo(cliModels.AlertSysConfig()),
+						}).Start()
+					},
+				)
+			},
+		},
+		{
+			Name:  "logs",
+			Usage: "Look into the stage's logs",
+			Subcommands: []cli.Command{
+				{
+					Name:  "alb",
+					Usage: "Summarize the load balancer access logs of a time window by status code and host",
+					Flags: []cli.Flag{StartTimeCliOpt.Flag(), EndTimeCliOpt.Flag()},
+					Action: func(c *cli.Context) error {
+						return runIfValidOptions(
+							c,
+							[]StringCliOption{&StageCliOpt},
+							validateLogWindowOptions,
+							func() {
+								cliModels := CliModels{Context: c}
+								config := cliModels.AlertSysConfig()
+								(&AlbAccessLogs{
+									Aws: cliModels.Aws(),
+									Bucket: cliModels.CloudFormationClient().StackOutput(
+										ServiceStackInfo(config), config.CfName(AccessLogBucketOutputName),
+									),
+									Prefix: config.AccessLogPrefix(),
+									Window: cliModels.LogWindow(),
+								}).Summarize()
+							},
+						)
 					},
+				},
+			},
+		},
+		{
+			Name:  "site",
+			Usage: "Manage a single WordPress site of the stage",
+			Subcommands: []cli.Command{
+				{
+					Name:  "maintenance",
+					Usage: "Answer every request for the site with a 503 and its maintenance page, or serve it again",
+					Subcommands: []cli.Command{
+						siteMaintenanceCommand(MaintenanceOn, "Put the site in maintenance"),
+						siteMaintenanceCommand(MaintenanceOff, "Take the site out of maintenance"),
+					},
+				},
+			},
+		},
+		{
+			Name:  "status",
+			Usage: "Show the status of the service stack and whether each site is serving or in maintenance",
+			Flags: []cli.Flag{WordPressSubDomainsOpt.Flag()},
+			Action: func(c *cli.Context) error {
+				return runIfValidOptions(
+					c,
+					[]StringCliOption{&StageCliOpt, &WordPressSubDomainsOpt},
+					validateWordPressSites,
 					func() {
-						NewAliasRecord(
-							(&CliModels{Context: c}).Aws().Route53(),
-							DomainCliOpt.Value(c),
-							HostedZoneIdCliOpt.Value(c),
-							ElbDomainNameCliOpt.Value(c),
-							ElbHostedZoneCliOpt.Value(c),
-							strings.Split(WordPressSubDomainsOpt.Value(c), wordPressSeparator),
-						).Create()
+						cliModels := CliModels{Context: c}
+						(&ServiceStatus{
+							Client:    cliModels.CloudFormationClient(),
+							StackInfo: ServiceStackInfo(cliModels.AlertSysConfig()),
+							Sites:     wordPressSites(c),
+						}).Print()
 					},
 				)
 			},
@@ -222,11 +325,37 @@ func main() {
 	app.Run(os.Args)
 }
 
+// siteMaintenanceCommand sets the maintenance parameter of the site given as argument, leaving the rest of the stack
+// as it is. The maintenance rule is created or deleted by the stack update.
+func siteMaintenanceCommand(mode string, usage string) cli.Command {
+	return cli.Command{
+		Name:      mode,
+		Usage:     usage,
+		ArgsUsage: "<site>",
+		Flags:     []cli.Flag{WordPressSubDomainsOpt.Flag()},
+		Action: func(c *cli.Context) error {
+			return runIfValidOptions(
+				c,
+				[]StringCliOption{&StageCliOpt, &WordPressSubDomainsOpt},
+				validateSiteArgument,
+				func() {
+					cliModels := CliModels{Context: c}
+					cliModels.CloudFormationClient().UpdateStackParameter(
+						ServiceStackInfo(cliModels.AlertSysConfig()), MaintenanceParamName(siteArgument(c)), mode,
+					)
+				},
+			)
+		},
+	}
+}
+
 type CloudFormationSubCommand struct {
 	writeFlags         []cli.Flag
 	writeRequiredOpts  []StringCliOption
 	createFlags        []cli.Flag
 	createRequiredOpts []StringCliOption
+	validate           func(context *cli.Context) error
+	createValidate     func(context *cli.Context) error
 	stackInfo          func(context *cli.Context) *StackInfo
 	templateCreator    func(context *cli.Context) *Template
 	parameters         func(context *cli.Context) []*cloudformation.Parameter
@@ -239,9 +368,10 @@ func (cfSubCmd *CloudFormationSubCommand) SubCommands() []cli.Command {
 			Usage: "Write the cloud formation stack to a local file - useful for debugging",
 			Flags: cfSubCmd.writeFlags,
 			Action: func(c *cli.Context) error {
-				return runIfRequiredOptions(
+				return runIfValidOptions(
 					c,
 					cfSubCmd.writeRequiredOpts,
+					cfSubCmd.validate,
 					func() {
 						(&CliModels{Context: c}).CloudFormationClient().WriteCloudFormationJsonTemplate(
 							cfSubCmd.stackInfo(c).TemplateFileName(),
@@ -256,9 +386,10 @@ func (cfSubCmd *CloudFormationSubCommand) SubCommands() []cli.Command {
 			Usage: "Create the cloud formation stack",
 			Flags: cfSubCmd.createFlags,
 			Action: func(c *cli.Context) error {
-				return runIfRequiredOptions(
+				return runIfValidOptions(
 					c,
 					cfSubCmd.createRequiredOpts,
+					cfSubCmd.createValidate,
 					func() {
 						(&CliModels{Context: c}).CloudFormationClient().CreateCloudFormationStack(
 							cfSubCmd.stackInfo(c),
@@ -274,9 +405,10 @@ func (cfSubCmd *CloudFormationSubCommand) SubCommands() []cli.Command {
 			Usage: "Update the cloud formation stack",
 			Flags: cfSubCmd.createFlags,
 			Action: func(c *cli.Context) error {
-				return runIfRequiredOptions(
+				return runIfValidOptions(
 					c,
 					cfSubCmd.createRequiredOpts,
+					cfSubCmd.createValidate,
 					func() {
 						(&CliModels{Context: c}).CloudFormationClient().UpdateCloudFormationStack(
 							cfSubCmd.stackInfo(c),
@@ -313,12 +445,126 @@ func (cfSubCmd *CloudFormationSubCommand) SubCommands() []cli.Command {
 }
 
 func runIfRequiredOptions(c *cli.Context, requiredOpts []StringCliOption, action func()) error {
+	return runIfValidOptions(c, requiredOpts, noValidation, action)
+}
+
+// runIfValidOptions runs the action if the required options are present and the validation passes. This allows
+// mistakes in the options to be reported before anything is generated or created.
+func runIfValidOptions(
+	c *cli.Context, requiredOpts []StringCliOption, validate func(c *cli.Context) error, action func(),
+) error {
 	for _, opt := range requiredOpts {
 		if opt.IsAbsent(c) {
 			return opt.ExitError()
 		}
 	}
 
+	if err := validate(c); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
 	action()
 	return actionSuccess
 }
+
+func noValidation(c *cli.Context) error {
+	return nil
+}
+
+func validateServiceOptions(c *cli.Context) error {
+	if err := validateWordPressSites(c); err != nil {
+		return err
+	}
+
+	cliModels := CliModels{Context: c}
+	serviceConfig := cliModels.ServiceConfig()
+	if err := serviceConfig.Validate(); err != nil {
+		return err
+	}
+
+	templateConfig := cliModels.AlertSysConfig()
+	if err := templateConfig.ValidateStage(); err != nil {
+		return err
+	}
+	if templateConfig.StageConfig().ExistingVpc == nil {
+		if err := templateConfig.ValidateAzCount(len(cliModels.Aws().Azs())); err != nil {
+			return err
+		}
+	}
+	if vpc := cliModels.ExistingVpc(); vpc != nil {
+		subnetAzs := cliModels.Aws().SubnetAzs(vpc.SubnetIds())
+		if err := templateConfig.ValidateSubnetAzs(vpc, subnetAzs); err != nil {
+			return err
+		}
+	}
+
+	if err := serviceConfig.ValidateSites(wordPressSites(c), DomainCliOpt.Value(c)); err != nil {
+		return err
+	}
+
+	if err := templateConfig.ValidateSiteAccess(wordPressSites(c)); err != nil {
+		return err
+	}
+
+	return templateConfig.ValidateSiteCapacity(wordPressSites(c))
+}
+
+// validateServiceCreateOptions also checks the options that are only needed to create or update the stack.
+func validateServiceCreateOptions(c *cli.Context) error {
+	if err := validateServiceOptions(c); err != nil {
+		return err
+	}
+
+	if (&CliModels{Context: c}).AlertSysConfig().UsesKeyPair() && Ec2KeyNameCliOpt.IsAbsent(c) {
+		return Ec2KeyNameCliOpt.ExitError()
+	}
+
+	return nil
+}
+
+func validateSessionOptions(c *cli.Context) error {
+	return (&CliModels{Context: c}).AlertSysConfig().ValidateHostSession()
+}
+
+func validateLogWindowOptions(c *cli.Context) error {
+	_, err := TimeWindowFromStrings(StartTimeCliOpt.Value(c), EndTimeCliOpt.Value(c), time.Now())
+	return err
+}
+
+func validateWordPressSites(c *cli.Context) error {
+	_, err := SitesFromString(WordPressSubDomainsOpt.Value(c), wordPressSeparator)
+	return err
+}
+
+// validateSiteArgument checks that the command's argument is one of the WordPress sites.
+func validateSiteArgument(c *cli.Context) error {
+	if err := validateWordPressSites(c); err != nil {
+		return err
+	}
+
+	if siteArgument(c) == nil {
+		return &UnknownSiteError{Name: c.Args().First(), Sites: wordPressSites(c)}
+	}
+
+	return nil
+}
+
+// siteArgument is the WordPress site named by the command's argument, or nil if there is no such site.
+func siteArgument(c *cli.Context) *Site {
+	for _, site := range wordPressSites(c) {
+		if site.Name == c.Args().First() {
+			return site
+		}
+	}
+
+	return nil
+}
+
+func wordPressSites(c *cli.Context) []*Site {
+	sites, err := SitesFromString(WordPressSubDomainsOpt.Value(c), wordPressSeparator)
+	if err != nil {
+		panic(err)
+	}
+
+	return sites
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+var defaultAccessLogRetentionDays = 90
+var defaultAccessLogWindow = time.Hour
+
+// AccessLogsConfig turns on the load balancer's access logs, which are delivered to a bucket that is part of the stack
+// and expires the logs after the retention period.
+type AccessLogsConfig struct {
+	// RetentionDays defaults to 90 days.
+	RetentionDays int
+}
+
+func (c *AccessLogsConfig) Retention() int {
+	if c.RetentionDays == 0 {
+		return defaultAccessLogRetentionDays
+	}
+	return c.RetentionDays
+}
+
+func (c *AccessLogsConfig) validate(stageName string, stageConfig *StageConfig) error {
+	if c.Retention() < 1 {
+		return stageConfig.invalidSetting(
+			stageName, "AccessLogs.RetentionDays", fmt.Sprint(c.RetentionDays), "must be at least 1",
+		)
+	}
+
+	return nil
+}
+
+// AccessLogPrefix is the prefix of the load balancer's access logs in the bucket, which keeps the stages apart.
+func (config *TemplateConfig) AccessLogPrefix() string {
+	return config.Stage.String()
+}
+
+type InvalidTimeError struct {
+	Value  string
+	Reason string
+}
+
+func (err *InvalidTimeError) Error() string {
+	return fmt.Sprintf("Time '%s' is not valid: %s", err.Value, err.Reason)
+}
+
+// A TimeWindow is the span of time to look at logs in.
+type TimeWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// TimeWindowFromStrings parses the RFC 3339 start and end times. The end defaults to now and the start to an hour
+// before the end.
+func TimeWindowFromStrings(start string, end string, now time.Time) (*TimeWindow, error) {
+	window := &TimeWindow{End: now}
+	if end != "" {
+		endTime, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return nil, &InvalidTimeError{Value: end, Reason: "must be an RFC 3339 time e.g. 2006-01-02T15:04:05Z"}
+		}
+		window.End = endTime
+	}
+
+	window.Start = window.End.Add(-defaultAccessLogWindow)
+	if start != "" {
+		startTime, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return nil, &InvalidTimeError{Value: start, Reason: "must be an RFC 3339 time e.g. 2006-01-02T15:04:05Z"}
+		}
+		window.Start = startTime
+	}
+
+	if !window.Start.Before(window.End) {
+		return nil, &InvalidTimeError{Value: start, Reason: "the start must be before the end"}
+	}
+
+	return window, nil
+}
+
+// Contains is whether the time falls within the window, including the start but not the end.
+func (window *TimeWindow) Contains(t time.Time) bool {
+	return !t.Before(window.Start) && t.Before(window.End)
+}
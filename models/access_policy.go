@@ -0,0 +1,141 @@
+package models
+
+import (
+	"fmt"
+	"net"
+)
+
+var CognitoAccessPolicy = "cognito"
+var OidcAccessPolicy = "oidc"
+var IpAllowlistAccessPolicy = "ip-allowlist"
+var accessPolicyTypes = []string{CognitoAccessPolicy, OidcAccessPolicy, IpAllowlistAccessPolicy}
+
+// a listener rule matches on at most 5 values, which the site's hosts and the allowed ranges share
+var maxListenerRuleConditionValues = 5
+
+// AccessPolicyConfig keeps the sites of a stage from the public, either by signing visitors in through a Cognito user
+// pool or an OIDC identity provider before they are forwarded to a site, or by only forwarding requests from the
+// allowed IP ranges. Requests that are not let through get a 403.
+type AccessPolicyConfig struct {
+	// Type is 'cognito', 'oidc' or 'ip-allowlist'.
+	Type string
+	// Cognito is the user pool visitors sign in with for the cognito type.
+	Cognito *CognitoAccessConfig
+	// Oidc is the identity provider visitors sign in with for the oidc type.
+	Oidc *OidcAccessConfig
+	// AllowedCidrs are the IPv4 and IPv6 ranges that may reach the sites for the ip-allowlist type.
+	AllowedCidrs []string
+}
+
+// CognitoAccessConfig is a user pool and an app client of it whose callback URLs are
+// 'https://<host>/oauth2/idpresponse' for every host of the sites.
+type CognitoAccessConfig struct {
+	UserPoolArn      string
+	UserPoolClientId string
+	// UserPoolDomain is the prefix or the full custom domain of the user pool's hosted sign in pages.
+	UserPoolDomain string
+}
+
+// OidcAccessConfig is a client of an OIDC identity provider whose redirect URIs are 'https://<host>/oauth2/idpresponse'
+// for every host of the sites.
+type OidcAccessConfig struct {
+	Issuer                string
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	UserInfoEndpoint      string
+	ClientId              string
+	// ClientSecret is best given as a dynamic reference e.g. '{{resolve:secretsmanager:gamma-oidc:SecretString:secret}}'
+	// so that it is kept out of the configuration file and the template.
+	ClientSecret string
+}
+
+// IsAuthenticating is whether visitors sign in before they are forwarded to a site.
+func (c *AccessPolicyConfig) IsAuthenticating() bool {
+	return c.Type == CognitoAccessPolicy || c.Type == OidcAccessPolicy
+}
+
+func (c *AccessPolicyConfig) validate(stageName string, stageConfig *StageConfig) error {
+	if stageConfig.CloudFront != nil {
+		return stageConfig.invalidSetting(
+			stageName, "AccessPolicy", c.Type, "cannot be combined with CloudFront, which would cache the sites for anyone",
+		)
+	}
+
+	var required []requiredAccessSetting
+	switch c.Type {
+	case CognitoAccessPolicy:
+		if c.Cognito == nil {
+			return stageConfig.invalidSetting(stageName, "AccessPolicy.Cognito", "", "is needed for the cognito type")
+		}
+		required = []requiredAccessSetting{
+			{"Cognito.UserPoolArn", c.Cognito.UserPoolArn},
+			{"Cognito.UserPoolClientId", c.Cognito.UserPoolClientId},
+			{"Cognito.UserPoolDomain", c.Cognito.UserPoolDomain},
+		}
+	case OidcAccessPolicy:
+		if c.Oidc == nil {
+			return stageConfig.invalidSetting(stageName, "AccessPolicy.Oidc", "", "is needed for the oidc type")
+		}
+		required = []requiredAccessSetting{
+			{"Oidc.Issuer", c.Oidc.Issuer},
+			{"Oidc.AuthorizationEndpoint", c.Oidc.AuthorizationEndpoint},
+			{"Oidc.TokenEndpoint", c.Oidc.TokenEndpoint},
+			{"Oidc.UserInfoEndpoint", c.Oidc.UserInfoEndpoint},
+			{"Oidc.ClientId", c.Oidc.ClientId},
+			{"Oidc.ClientSecret", c.Oidc.ClientSecret},
+		}
+	case IpAllowlistAccessPolicy:
+		if len(c.AllowedCidrs) == 0 {
+			return stageConfig.invalidSetting(
+				stageName, "AccessPolicy.AllowedCidrs", "", "needs at least one range for the ip-allowlist type",
+			)
+		}
+		for _, cidr := range c.AllowedCidrs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return stageConfig.invalidSetting(stageName, "AccessPolicy.AllowedCidrs", cidr, "must be a CIDR block")
+			}
+		}
+	default:
+		return stageConfig.invalidSetting(
+			stageName, "AccessPolicy.Type", c.Type, fmt.Sprintf("choose from %s", accessPolicyTypes),
+		)
+	}
+
+	for _, setting := range required {
+		if setting.value == "" {
+			return stageConfig.invalidSetting(
+				stageName, "AccessPolicy."+setting.setting, "", fmt.Sprintf("is needed for the %s type", c.Type),
+			)
+		}
+	}
+
+	return nil
+}
+
+type requiredAccessSetting struct {
+	setting string
+	value   string
+}
+
+// ValidateSiteAccess checks that the allowed ranges fit in the listener rule of each site next to its hosts.
+func (config *TemplateConfig) ValidateSiteAccess(sites []*Site) error {
+	accessPolicy := config.StageConfig().AccessPolicy
+	if accessPolicy == nil || accessPolicy.Type != IpAllowlistAccessPolicy {
+		return nil
+	}
+
+	for _, site := range sites {
+		hosts := len(config.SiteHostLabels(site))
+		if hosts+len(accessPolicy.AllowedCidrs) > maxListenerRuleConditionValues {
+			return config.StageConfig().invalidSetting(
+				config.Stage.String(), "AccessPolicy.AllowedCidrs", fmt.Sprint(accessPolicy.AllowedCidrs),
+				fmt.Sprintf(
+					"site '%s' is served at %d hosts, which leaves room for %d ranges in its listener rule",
+					site.Name, hosts, maxListenerRuleConditionValues-hosts,
+				),
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestAccessPolicyConfigValidate(t *testing.T) {
+	cognito := &CognitoAccessConfig{
+		UserPoolArn:      "arn:aws:cognito-idp:us-west-2:123456789012:userpool/us-west-2_abc",
+		UserPoolClientId: "client",
+		UserPoolDomain:   "gamma-sign-in",
+	}
+	oidc := &OidcAccessConfig{
+		Issuer:                "https://idp.example.com",
+		AuthorizationEndpoint: "https://idp.example.com/authorize",
+		TokenEndpoint:         "https://idp.example.com/token",
+		UserInfoEndpoint:      "https://idp.example.com/userinfo",
+		ClientId:              "client",
+		ClientSecret:          "{{resolve:secretsmanager:gamma-oidc:SecretString:secret}}",
+	}
+
+	for _, test := range []struct {
+		name        string
+		stageConfig StageConfig
+		setting     string
+	}{
+		{"cognito", StageConfig{AccessPolicy: &AccessPolicyConfig{Type: CognitoAccessPolicy, Cognito: cognito}}, ""},
+		{"oidc", StageConfig{AccessPolicy: &AccessPolicyConfig{Type: OidcAccessPolicy, Oidc: oidc}}, ""},
+		{
+			"allowlist",
+			StageConfig{AccessPolicy: &AccessPolicyConfig{
+				Type: IpAllowlistAccessPolicy, AllowedCidrs: []string{"203.0.113.0/24", "2001:db8::/32"},
+			}},
+			"",
+		},
+		{"unknown type", StageConfig{AccessPolicy: &AccessPolicyConfig{Type: "basic"}}, "AccessPolicy.Type"},
+		{
+			"cognito without a user pool",
+			StageConfig{AccessPolicy: &AccessPolicyConfig{Type: CognitoAccessPolicy, Oidc: oidc}},
+			"AccessPolicy.Cognito",
+		},
+		{
+			"cognito without a domain",
+			StageConfig{AccessPolicy: &AccessPolicyConfig{
+				Type:    CognitoAccessPolicy,
+				Cognito: &CognitoAccessConfig{UserPoolArn: cognito.UserPoolArn, UserPoolClientId: "client"},
+			}},
+			"AccessPolicy.Cognito.UserPoolDomain",
+		},
+		{
+			"oidc without a provider",
+			StageConfig{AccessPolicy: &AccessPolicyConfig{Type: OidcAccessPolicy}},
+			"AccessPolicy.Oidc",
+		},
+		{
+			"oidc without a client secret",
+			StageConfig{AccessPolicy: &AccessPolicyConfig{
+				Type: OidcAccessPolicy,
+				Oidc: &OidcAccessConfig{
+					Issuer: oidc.Issuer, AuthorizationEndpoint: oidc.AuthorizationEndpoint,
+					TokenEndpoint: oidc.TokenEndpoint, UserInfoEndpoint: oidc.UserInfoEndpoint, ClientId: "client",
+				},
+			}},
+			"AccessPolicy.Oidc.ClientSecret",
+		},
+		{
+			"empty allowlist",
+			StageConfig{AccessPolicy: &AccessPolicyConfig{Type: IpAllowlistAccessPolicy}},
+			"AccessPolicy.AllowedCidrs",
+		},
+		{
+			"allowlist of addresses",
+			StageConfig{AccessPolicy: &AccessPolicyConfig{
+				Type: IpAllowlistAccessPolicy, AllowedCidrs: []string{"203.0.113.7"},
+			}},
+			"AccessPolicy.AllowedCidrs",
+		},
+		{
+			"behind CloudFront",
+			StageConfig{
+				AccessPolicy: &AccessPolicyConfig{Type: CognitoAccessPolicy, Cognito: cognito},
+				CloudFront:   &CloudFrontConfig{},
+			},
+			"AccessPolicy",
+		},
+	} {
+		err := test.stageConfig.AccessPolicy.validate("Gamma", &test.stageConfig)
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		if settingError, ok := err.(*InvalidStageSettingError); !ok || settingError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
+
+func TestValidateSiteAccess(t *testing.T) {
+	sites := sitesNamed("blog", "shop")
+	siteConfigs := map[string]*SiteConfig{"shop": {Apex: true, Www: true}}
+
+	for _, test := range []struct {
+		name         string
+		accessPolicy *AccessPolicyConfig
+		valid        bool
+	}{
+		{"no access policy", nil, true},
+		{"sign in", &AccessPolicyConfig{Type: CognitoAccessPolicy}, true},
+		{
+			"ranges fit next to the hosts",
+			&AccessPolicyConfig{Type: IpAllowlistAccessPolicy, AllowedCidrs: []string{"10.0.0.0/8", "192.0.2.0/24"}},
+			true,
+		},
+		{
+			"ranges crowd out the hosts of a site",
+			&AccessPolicyConfig{
+				Type: IpAllowlistAccessPolicy, AllowedCidrs: []string{"10.0.0.0/8", "192.0.2.0/24", "198.51.100.0/24"},
+			},
+			false,
+		},
+	} {
+		config := &TemplateConfig{
+			Stage: &GammaStage,
+			Service: &ServiceConfig{
+				Stages: map[string]*StageConfig{"Gamma": {AccessPolicy: test.accessPolicy}},
+				Sites:  siteConfigs,
+			},
+		}
+
+		err := config.ValidateSiteAccess(sites)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid to be %t, got %v", test.name, test.valid, err)
+		}
+	}
+}
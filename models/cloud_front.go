@@ -0,0 +1,59 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+var defaultCloudFrontPriceClass = "PriceClass_100"
+var cloudFrontPriceClasses = []string{"PriceClass_100", "PriceClass_200", "PriceClass_All"}
+
+// CloudFront only takes certificates from this region, wherever the stack is
+var cloudFrontCertificatePrefix = "arn:aws:acm:us-east-1:"
+
+// CloudFrontConfig puts a CloudFront distribution in front of the load balancer, which caches the static files of the
+// sites at the edge. The alias records of the sites point at the distribution instead of the load balancer.
+type CloudFrontConfig struct {
+	// CertificateArn is a certificate in us-east-1 covering every host of the sites, including the domains of their own.
+	CertificateArn string
+	// PriceClass is 'PriceClass_100', 'PriceClass_200' or 'PriceClass_All'. Defaults to 'PriceClass_100'.
+	PriceClass string
+}
+
+func (c *CloudFrontConfig) Prices() string {
+	if c.PriceClass == "" {
+		return defaultCloudFrontPriceClass
+	}
+	return c.PriceClass
+}
+
+func (c *CloudFrontConfig) validate(stageName string, stageConfig *StageConfig) error {
+	if !strings.HasPrefix(c.CertificateArn, cloudFrontCertificatePrefix) {
+		return stageConfig.invalidSetting(
+			stageName, "CloudFront.CertificateArn", c.CertificateArn, "must be an ACM certificate in us-east-1",
+		)
+	}
+
+	if !containsString(cloudFrontPriceClasses, c.Prices()) {
+		return stageConfig.invalidSetting(
+			stageName, "CloudFront.PriceClass", c.PriceClass, fmt.Sprintf("choose from %s", cloudFrontPriceClasses),
+		)
+	}
+
+	// the web ACL of the load balancer would only see the IPs of the edge locations, which it can neither rate limit
+	// one visitor at a time nor block ranges by
+	if stageConfig.Waf != nil {
+		return stageConfig.invalidSetting(stageName, "Waf", "", "cannot be combined with CloudFront")
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestCloudFrontConfigValidate(t *testing.T) {
+	certificateArn := "arn:aws:acm:us-east-1:123456789012:certificate/abc"
+
+	for _, test := range []struct {
+		name        string
+		stageConfig StageConfig
+		setting     string
+	}{
+		{"defaults", StageConfig{CloudFront: &CloudFrontConfig{CertificateArn: certificateArn}}, ""},
+		{
+			"every edge location",
+			StageConfig{CloudFront: &CloudFrontConfig{CertificateArn: certificateArn, PriceClass: "PriceClass_All"}},
+			"",
+		},
+		{"no certificate", StageConfig{CloudFront: &CloudFrontConfig{}}, "CloudFront.CertificateArn"},
+		{
+			"certificate outside us-east-1",
+			StageConfig{CloudFront: &CloudFrontConfig{
+				CertificateArn: "arn:aws:acm:us-west-2:123456789012:certificate/abc",
+			}},
+			"CloudFront.CertificateArn",
+		},
+		{
+			"unknown price class",
+			StageConfig{CloudFront: &CloudFrontConfig{CertificateArn: certificateArn, PriceClass: "PriceClass_50"}},
+			"CloudFront.PriceClass",
+		},
+		{
+			"web ACL on the load balancer",
+			StageConfig{CloudFront: &CloudFrontConfig{CertificateArn: certificateArn}, Waf: &WafConfig{}},
+			"Waf",
+		},
+	} {
+		err := test.stageConfig.CloudFront.validate("Gamma", &test.stageConfig)
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		if settingError, ok := err.(*InvalidStageSettingError); !ok || settingError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
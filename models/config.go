@@ -8,13 +8,20 @@ import (
 // A configuration needed to build the cloud formation template.
 type TemplateConfig struct {
 	*Stage
-	Region *Region
+	Region  *Region
+	Service *ServiceConfig
+}
+
+func (config *TemplateConfig) StageConfig() *StageConfig {
+	return config.Service.StageConfig(config.Stage)
 }
 
 // A Region is the AWS region. Instead of creating a region, consider using one of the pre-defined regions in this
 // package.
 type Region struct {
 	name string
+	// elbAccountId is the account that delivers the load balancer access logs in the region
+	elbAccountId string
 }
 
 func (region *Region) String() string {
@@ -25,6 +32,10 @@ func (region *Region) StringExpr() *StringExpr {
 	return String(region.name)
 }
 
+func (region *Region) ElbAccountId() string {
+	return region.elbAccountId
+}
+
 func RegionFromString(regionName string) *Region {
 	for _, region := range []Region{UsEast1, UsWest2} {
 		if region.name == regionName {
@@ -70,5 +81,5 @@ var GammaStage = Stage{name: gammaStageName}
 var ProdStage = Stage{name: prodStageName}
 
 var DefaultRegion = UsWest2
-var UsWest2 = Region{"us-west-2"}
-var UsEast1 = Region{"us-east-1"}
+var UsWest2 = Region{"us-west-2", "797873946194"}
+var UsEast1 = Region{"us-east-1", "127311923021"}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+var vpcIdPrefix = "vpc-"
+
+// ExistingVpcConfig points a stage at a VPC that is managed outside of the stack, in which case the stack creates no
+// VPC, subnets, gateways or route tables. Each tier's subnets are either listed by id or discovered by their tags, and
+// the application subnets default to the public subnets.
+type ExistingVpcConfig struct {
+	VpcId            string
+	PublicSubnetIds  []string
+	PublicSubnetTags map[string]string
+	AppSubnetIds     []string
+	AppSubnetTags    map[string]string
+}
+
+// ExistingVpc is the VPC a stack is deployed into, with the subnets of each tier resolved to their ids.
+type ExistingVpc struct {
+	VpcId           string
+	PublicSubnetIds []string
+	AppSubnetIds    []string
+}
+
+// HasAppSubnets is whether the application subnets were configured separately from the public subnets.
+func (c *ExistingVpcConfig) HasAppSubnets() bool {
+	return len(c.AppSubnetIds) > 0 || len(c.AppSubnetTags) > 0
+}
+
+// SubnetIds are the ids of the subnets of both tiers, each listed once.
+func (vpc *ExistingVpc) SubnetIds() []string {
+	var subnetIds []string
+	seen := map[string]bool{}
+	for _, subnetId := range append(append([]string{}, vpc.PublicSubnetIds...), vpc.AppSubnetIds...) {
+		if !seen[subnetId] {
+			seen[subnetId] = true
+			subnetIds = append(subnetIds, subnetId)
+		}
+	}
+
+	return subnetIds
+}
+
+func (c *ExistingVpcConfig) validate(stageName string, stageConfig *StageConfig) error {
+	if !strings.HasPrefix(c.VpcId, vpcIdPrefix) {
+		return stageConfig.invalidSetting(stageName, "ExistingVpc.VpcId", c.VpcId, "must be a VPC id e.g. 'vpc-1a2b3c4d'")
+	}
+
+	if len(c.PublicSubnetIds) == 0 && len(c.PublicSubnetTags) == 0 {
+		return stageConfig.invalidSetting(
+			stageName, "ExistingVpc.PublicSubnetIds", "", "either the public subnet ids or tags are required",
+		)
+	}
+
+	for _, tier := range []struct {
+		name string
+		ids  []string
+		tags map[string]string
+	}{
+		{"Public", c.PublicSubnetIds, c.PublicSubnetTags},
+		{"App", c.AppSubnetIds, c.AppSubnetTags},
+	} {
+		if len(tier.ids) > 0 && len(tier.tags) > 0 {
+			return stageConfig.invalidSetting(
+				stageName, "ExistingVpc."+tier.name+"SubnetTags", "", "give either the subnet ids or the tags, not both",
+			)
+		}
+	}
+
+	// the settings that shape a VPC created by the stack mean nothing for a VPC managed elsewhere
+	for _, setting := range []struct {
+		name  string
+		isSet bool
+	}{
+		{"PrivateSubnets", stageConfig.PrivateSubnets},
+		{"NatGateways", stageConfig.NatGateways != ""},
+		{"VpcCidr", stageConfig.VpcCidr != ""},
+		{"SubnetCount", stageConfig.SubnetCount != 0},
+		{"AzCount", stageConfig.AzCount != 0},
+		{"PublicSubnetPrefix", stageConfig.PublicSubnetPrefix != 0},
+		{"PrivateSubnetPrefix", stageConfig.PrivateSubnetPrefix != 0},
+		{"VpcEndpoints", len(stageConfig.VpcEndpoints) > 0},
+		{"FlowLogs", stageConfig.FlowLogs != nil},
+		{"NetworkAcls", stageConfig.NetworkAcls},
+		{"DualStack", stageConfig.DualStack},
+	} {
+		if setting.isSet {
+			return stageConfig.invalidSetting(stageName, setting.name, "", "cannot be used with an existing VPC")
+		}
+	}
+
+	return nil
+}
+
+// ValidateSubnetAzs checks that no two subnets of a tier share an availability zone, given the zone of each subnet. The
+// load balancer takes a single subnet per zone, and so does EFS for its mount targets in the application subnets.
+func (config *TemplateConfig) ValidateSubnetAzs(vpc *ExistingVpc, subnetAzs map[string]string) error {
+	stageConfig := config.StageConfig()
+	for _, tier := range []struct {
+		setting   string
+		subnetIds []string
+	}{
+		{"ExistingVpc.PublicSubnetIds", vpc.PublicSubnetIds},
+		{"ExistingVpc.AppSubnetIds", vpc.AppSubnetIds},
+	} {
+		subnetsByAz := map[string]string{}
+		for _, subnetId := range tier.subnetIds {
+			az := subnetAzs[subnetId]
+			if otherSubnetId, exists := subnetsByAz[az]; exists {
+				return stageConfig.invalidSetting(
+					config.Stage.name, tier.setting, fmt.Sprint(tier.subnetIds),
+					fmt.Sprintf(
+						"subnets '%s' and '%s' are both in %s, use one subnet per availability zone", otherSubnetId,
+						subnetId, az,
+					),
+				)
+			}
+			subnetsByAz[az] = subnetId
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"fmt"
+)
+
+var defaultFargate = FargateConfig{
+	Cpu:      512,
+	MemoryMb: 1024,
+}
+
+// fargateMemoryRanges are the memory sizes Fargate offers for each task CPU size, in steps of 1 GB apart from 512 MB
+var fargateMemoryRanges = map[int]struct {
+	min int
+	max int
+}{
+	256:  {512, 2048},
+	512:  {1024, 4096},
+	1024: {2048, 8192},
+	2048: {4096, 16384},
+	4096: {8192, 30720},
+}
+
+// FargateConfig runs the sites' tasks on Fargate instead of ECS hosts, which leaves out the launch configuration and
+// the auto scaling group. The files and databases of the sites stay on EFS.
+type FargateConfig struct {
+	// Cpu is the CPU units of each site's task: 256, 512, 1024, 2048 or 4096. Defaults to 512.
+	Cpu int
+	// MemoryMb is the memory of each site's task, which has to be one Fargate offers for the CPU e.g. 1024 to 4096 in
+	// steps of 1024 for 512 CPU units. Defaults to 1024.
+	MemoryMb int
+}
+
+// UsesFargate is whether the sites run on Fargate rather than on ECS hosts.
+func (config *TemplateConfig) UsesFargate() bool {
+	return config.StageConfig().Fargate != nil
+}
+
+// ValidateHostSession checks that the stage has hosts to open a session to, which it does not on Fargate, and that they
+// run the SSM agent, which they only do with SessionManager.
+func (config *TemplateConfig) ValidateHostSession() error {
+	stageConfig := config.StageConfig()
+	if config.UsesFargate() {
+		return stageConfig.invalidSetting(
+			config.Stage.name, "Fargate", "", "the tasks run without hosts to open a session to",
+		)
+	}
+	if !stageConfig.SessionManager {
+		return stageConfig.invalidSetting(
+			config.Stage.name, "SessionManager", "false",
+			"the hosts only run the SSM agent with SessionManager set to true, so turn it on and update the stack",
+		)
+	}
+
+	return nil
+}
+
+// FargateSettings is the Fargate settings of the stage, with the defaults filled in.
+func (config *TemplateConfig) FargateSettings() *FargateConfig {
+	settings := defaultFargate
+	if stageSettings := config.StageConfig().Fargate; stageSettings != nil {
+		settings.override(stageSettings)
+	}
+
+	return &settings
+}
+
+func (c *FargateConfig) override(other *FargateConfig) {
+	if other.Cpu != 0 {
+		c.Cpu = other.Cpu
+	}
+	if other.MemoryMb != 0 {
+		c.MemoryMb = other.MemoryMb
+	}
+}
+
+func (c *FargateConfig) validate(stageName string, stageConfig *StageConfig) error {
+	if stageConfig.Instances != nil {
+		return stageConfig.invalidSetting(stageName, "Instances", "", "has no hosts to size with Fargate")
+	}
+	if len(stageConfig.AdminCidrs) > 0 {
+		return stageConfig.invalidSetting(
+			stageName, "AdminCidrs", fmt.Sprint(stageConfig.AdminCidrs), "there are no hosts to SSH into with Fargate",
+		)
+	}
+	if stageConfig.SessionManager {
+		return stageConfig.invalidSetting(
+			stageName, "SessionManager", "true", "there are no hosts to open a session to with Fargate",
+		)
+	}
+
+	settings := defaultFargate
+	settings.override(c)
+
+	memoryRange, exists := fargateMemoryRanges[settings.Cpu]
+	if !exists {
+		return stageConfig.invalidSetting(
+			stageName, "Fargate.Cpu", fmt.Sprint(c.Cpu), "choose from [256 512 1024 2048 4096]",
+		)
+	}
+
+	if settings.MemoryMb < memoryRange.min || settings.MemoryMb > memoryRange.max ||
+		(settings.MemoryMb != 512 && settings.MemoryMb%1024 != 0) {
+		return stageConfig.invalidSetting(
+			stageName, "Fargate.MemoryMb", fmt.Sprint(settings.MemoryMb),
+			fmt.Sprintf(
+				"must be between %d and %d in steps of 1024 for %d CPU units", memoryRange.min, memoryRange.max,
+				settings.Cpu,
+			),
+		)
+	}
+
+	return nil
+}
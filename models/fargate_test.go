@@ -0,0 +1,87 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestFargateValidate(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		stageConfig StageConfig
+		setting     string
+	}{
+		{"defaults", StageConfig{Fargate: &FargateConfig{}}, ""},
+		{"smallest task", StageConfig{Fargate: &FargateConfig{Cpu: 256, MemoryMb: 512}}, ""},
+		{"most memory for 256 CPU units", StageConfig{Fargate: &FargateConfig{Cpu: 256, MemoryMb: 2048}}, ""},
+		{"least memory for 2048 CPU units", StageConfig{Fargate: &FargateConfig{Cpu: 2048, MemoryMb: 4096}}, ""},
+		{"largest task", StageConfig{Fargate: &FargateConfig{Cpu: 4096, MemoryMb: 30720}}, ""},
+		{"unknown CPU", StageConfig{Fargate: &FargateConfig{Cpu: 768}}, "Fargate.Cpu"},
+		{"default memory too little", StageConfig{Fargate: &FargateConfig{Cpu: 2048}}, "Fargate.MemoryMb"},
+		{"too much memory", StageConfig{Fargate: &FargateConfig{Cpu: 256, MemoryMb: 3072}}, "Fargate.MemoryMb"},
+		{"too little memory", StageConfig{Fargate: &FargateConfig{MemoryMb: 512}}, "Fargate.MemoryMb"},
+		{"memory not in steps", StageConfig{Fargate: &FargateConfig{Cpu: 1024, MemoryMb: 2560}}, "Fargate.MemoryMb"},
+		{"above the largest", StageConfig{Fargate: &FargateConfig{Cpu: 4096, MemoryMb: 31744}}, "Fargate.MemoryMb"},
+		{"instances", StageConfig{Fargate: &FargateConfig{}, Instances: &InstancesConfig{}}, "Instances"},
+		{"admin ranges", StageConfig{Fargate: &FargateConfig{}, AdminCidrs: []string{"10.0.0.0/8"}}, "AdminCidrs"},
+		{"session manager", StageConfig{Fargate: &FargateConfig{}, SessionManager: true}, "SessionManager"},
+	} {
+		err := test.stageConfig.Fargate.validate("Gamma", &test.stageConfig)
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		if settingError, ok := err.(*InvalidStageSettingError); !ok || settingError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
+
+func TestFargateContainerSize(t *testing.T) {
+	for _, test := range []struct {
+		fargate  *FargateConfig
+		expected ContainerSize
+	}{
+		{&FargateConfig{}, ContainerSize{CpuUnits: 256, MemoryMb: 512}},
+		{&FargateConfig{Cpu: 256, MemoryMb: 512}, ContainerSize{CpuUnits: 128, MemoryMb: 256}},
+		{&FargateConfig{Cpu: 4096, MemoryMb: 30720}, ContainerSize{CpuUnits: 2048, MemoryMb: 15360}},
+	} {
+		config := &TemplateConfig{
+			Stage:   &GammaStage,
+			Service: &ServiceConfig{Stages: map[string]*StageConfig{"Gamma": {Fargate: test.fargate}}},
+		}
+
+		// the sites do not share a task on Fargate, so their number does not matter
+		size, err := config.ContainerSize(20)
+		if err != nil {
+			t.Errorf("%+v: unexpected error: %s", *test.fargate, err)
+			continue
+		}
+		if *size != test.expected {
+			t.Errorf("%+v: expected %+v, got %+v", *test.fargate, test.expected, *size)
+		}
+	}
+}
+
+func TestValidateHostSession(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		stageConfig *StageConfig
+		valid       bool
+	}{
+		{"hosts", &StageConfig{}, false},
+		{"session manager", &StageConfig{SessionManager: true}, true},
+		{"Fargate", &StageConfig{Fargate: &FargateConfig{}}, false},
+	} {
+		config := &TemplateConfig{
+			Stage:   &GammaStage,
+			Service: &ServiceConfig{Stages: map[string]*StageConfig{"Gamma": test.stageConfig}},
+		}
+
+		if err := config.ValidateHostSession(); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid to be %t, got %v", test.name, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import "fmt"
+
+var CloudWatchLogsFlowLogDestination = "cloud-watch-logs"
+var S3FlowLogDestination = "s3"
+
+var defaultFlowLogRetentionDays = 90
+var defaultFlowLogTrafficType = "ALL"
+
+// the retention periods CloudWatch Logs accepts
+var logGroupRetentionDays = []int{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1827, 3653}
+
+// FlowLogsConfig records the IP traffic in the VPC, either to a CloudWatch Logs log group or to an S3 bucket. The log
+// group or bucket is part of the stack and expires the records after the retention period.
+type FlowLogsConfig struct {
+	// Destination is either 'cloud-watch-logs' or 's3'. Defaults to CloudWatch Logs.
+	Destination string
+	// RetentionDays defaults to 90 days.
+	RetentionDays int
+	// TrafficType is 'ACCEPT', 'REJECT' or 'ALL'. Defaults to all traffic.
+	TrafficType string
+}
+
+func (c *FlowLogsConfig) IsS3Destination() bool {
+	return c.Destination == S3FlowLogDestination
+}
+
+func (c *FlowLogsConfig) Retention() int {
+	if c.RetentionDays == 0 {
+		return defaultFlowLogRetentionDays
+	}
+	return c.RetentionDays
+}
+
+func (c *FlowLogsConfig) Traffic() string {
+	if c.TrafficType == "" {
+		return defaultFlowLogTrafficType
+	}
+	return c.TrafficType
+}
+
+func (c *FlowLogsConfig) validate(stageName string, stageConfig *StageConfig) error {
+	switch c.Destination {
+	case "", CloudWatchLogsFlowLogDestination, S3FlowLogDestination:
+	default:
+		return stageConfig.invalidSetting(
+			stageName, "FlowLogs.Destination", c.Destination,
+			fmt.Sprintf("choose from %s", []string{CloudWatchLogsFlowLogDestination, S3FlowLogDestination}),
+		)
+	}
+
+	switch c.TrafficType {
+	case "", "ACCEPT", "REJECT", "ALL":
+	default:
+		return stageConfig.invalidSetting(
+			stageName, "FlowLogs.TrafficType", c.TrafficType,
+			fmt.Sprintf("choose from %s", []string{"ACCEPT", "REJECT", "ALL"}),
+		)
+	}
+
+	if c.Retention() < 1 {
+		return stageConfig.invalidSetting(
+			stageName, "FlowLogs.RetentionDays", fmt.Sprint(c.RetentionDays), "must be at least 1",
+		)
+	}
+
+	if !c.IsS3Destination() && !containsInt(logGroupRetentionDays, c.Retention()) {
+		return stageConfig.invalidSetting(
+			stageName, "FlowLogs.RetentionDays", fmt.Sprint(c.RetentionDays),
+			fmt.Sprintf("CloudWatch Logs only keeps logs for %v days", logGroupRetentionDays),
+		)
+	}
+
+	return nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestFlowLogsConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		flowLogs FlowLogsConfig
+		setting  string
+	}{
+		{"defaults", FlowLogsConfig{}, ""},
+		{"rejected traffic kept for two weeks", FlowLogsConfig{TrafficType: "REJECT", RetentionDays: 14}, ""},
+		{"any retention in S3", FlowLogsConfig{Destination: S3FlowLogDestination, RetentionDays: 10}, ""},
+		{"unknown destination", FlowLogsConfig{Destination: "kinesis"}, "FlowLogs.Destination"},
+		{"lower case traffic type", FlowLogsConfig{TrafficType: "accept"}, "FlowLogs.TrafficType"},
+		{"retention CloudWatch Logs lacks", FlowLogsConfig{RetentionDays: 10}, "FlowLogs.RetentionDays"},
+		{
+			"negative retention in S3",
+			FlowLogsConfig{Destination: S3FlowLogDestination, RetentionDays: -1},
+			"FlowLogs.RetentionDays",
+		},
+	} {
+		err := test.flowLogs.validate("Gamma", &StageConfig{FlowLogs: &test.flowLogs})
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		if settingError, ok := err.(*InvalidStageSettingError); !ok || settingError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
+
+func TestFlowLogsConfigDefaults(t *testing.T) {
+	flowLogs := &FlowLogsConfig{}
+
+	if flowLogs.IsS3Destination() || flowLogs.Retention() != 90 || flowLogs.Traffic() != "ALL" {
+		t.Errorf("expected all traffic kept in CloudWatch Logs for 90 days, got %+v", flowLogs)
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var defaultImages = ImagesConfig{
+	WordPress: "wordpress",
+	Database:  "mariadb:10.3.2",
+}
+
+var ecrImagePattern = regexp.MustCompile(`^\d{12}\.dkr\.ecr\.[a-z0-9-]+\.amazonaws\.com/`)
+
+// ImagesConfig holds the container images the sites run, which can be mirrored into ECR so that the tasks do not need
+// Docker Hub.
+type ImagesConfig struct {
+	// WordPress is the image of the WordPress containers. Defaults to 'wordpress' from Docker Hub.
+	WordPress string
+	// Database is the image of the database containers, which has to be compatible with MariaDB 10.3. Defaults to
+	// 'mariadb:10.3.2' from Docker Hub.
+	Database string
+}
+
+// ImageSettings is the images of the stage, with the defaults filled in.
+func (config *TemplateConfig) ImageSettings() *ImagesConfig {
+	settings := defaultImages
+	if stageSettings := config.StageConfig().Images; stageSettings != nil {
+		settings.override(stageSettings)
+	}
+
+	return &settings
+}
+
+func (c *ImagesConfig) override(other *ImagesConfig) {
+	if other.WordPress != "" {
+		c.WordPress = other.WordPress
+	}
+	if other.Database != "" {
+		c.Database = other.Database
+	}
+}
+
+// validateFromEcr checks that both images are pulled from ECR, as the stage has no way to reach Docker Hub.
+func (c *ImagesConfig) validateFromEcr(stageName string, stageConfig *StageConfig) error {
+	settings := defaultImages
+	settings.override(c)
+
+	for _, image := range []struct {
+		setting string
+		value   string
+	}{
+		{"Images.WordPress", settings.WordPress},
+		{"Images.Database", settings.Database},
+	} {
+		if !ecrImagePattern.MatchString(image.value) {
+			return stageConfig.invalidSetting(
+				stageName, image.setting, image.value,
+				fmt.Sprintf(
+					"must be an ECR image e.g. '123456789012.dkr.ecr.us-west-2.amazonaws.com/wordpress' with "+
+						"NatGateways '%s'", NoNatGateway,
+				),
+			)
+		}
+	}
+
+	return nil
+}
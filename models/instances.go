@@ -0,0 +1,219 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+var ecsCpuUnitsPerVcpu int64 = 1024
+
+// each task runs a WordPress and a database container, which share the task's share of an instance evenly
+var containersPerTask int64 = 2
+var minContainerCpuUnits int64 = 64
+var minContainerMemoryMb int64 = 128
+
+// the memory of a host that ECS does not register, see EcsMemoryMb
+var kernelMemoryShare int64 = 16
+var hostReservedMemoryMb int64 = 128
+
+var defaultInstances = InstancesConfig{
+	Type:    "t2.micro",
+	MinSize: 1,
+}
+
+// An InstanceType is the CPU and memory of an EC2 instance type.
+type InstanceType struct {
+	Vcpus    int64
+	MemoryMb int64
+}
+
+// InstanceTypes are the instance types the ECS hosts can be, which the tasks are sized against.
+var InstanceTypes = map[string]InstanceType{
+	"t2.micro":   {Vcpus: 1, MemoryMb: 1024},
+	"t2.small":   {Vcpus: 1, MemoryMb: 2048},
+	"t2.medium":  {Vcpus: 2, MemoryMb: 4096},
+	"t2.large":   {Vcpus: 2, MemoryMb: 8192},
+	"t2.xlarge":  {Vcpus: 4, MemoryMb: 16384},
+	"t3.micro":   {Vcpus: 2, MemoryMb: 1024},
+	"t3.small":   {Vcpus: 2, MemoryMb: 2048},
+	"t3.medium":  {Vcpus: 2, MemoryMb: 4096},
+	"t3.large":   {Vcpus: 2, MemoryMb: 8192},
+	"t3.xlarge":  {Vcpus: 4, MemoryMb: 16384},
+	"t3a.micro":  {Vcpus: 2, MemoryMb: 1024},
+	"t3a.small":  {Vcpus: 2, MemoryMb: 2048},
+	"t3a.medium": {Vcpus: 2, MemoryMb: 4096},
+	"t3a.large":  {Vcpus: 2, MemoryMb: 8192},
+	"t3a.xlarge": {Vcpus: 4, MemoryMb: 16384},
+	"m5.large":   {Vcpus: 2, MemoryMb: 8192},
+	"m5.xlarge":  {Vcpus: 4, MemoryMb: 16384},
+	"m5.2xlarge": {Vcpus: 8, MemoryMb: 32768},
+	"c5.large":   {Vcpus: 2, MemoryMb: 4096},
+	"c5.xlarge":  {Vcpus: 4, MemoryMb: 8192},
+	"r5.large":   {Vcpus: 2, MemoryMb: 16384},
+}
+
+// CpuUnits is the CPU ECS can place tasks on, in units of 1/1024 of a vCPU.
+func (t InstanceType) CpuUnits() int64 {
+	return t.Vcpus * ecsCpuUnitsPerVcpu
+}
+
+// EcsMemoryMb is the memory ECS can place tasks in. The agent registers less memory than the instance has, as the
+// kernel takes a share that grows with the memory and the OS and agent take some more, so 1/16 of the memory and a
+// fixed 128 MB are held back e.g. 832 MB of a t2.micro and 7552 MB of an m5.large.
+func (t InstanceType) EcsMemoryMb() int64 {
+	return t.MemoryMb - t.MemoryMb/kernelMemoryShare - hostReservedMemoryMb
+}
+
+// InstancesConfig holds the instance type and the capacity of the auto scaling group of the ECS hosts.
+type InstancesConfig struct {
+	// Type is the instance type of the hosts, which must be one of the known instance types. Defaults to 't2.micro'.
+	Type string
+	// MinSize, MaxSize and DesiredCapacity are the bounds and the number of hosts. MinSize defaults to 1,
+	// DesiredCapacity to MinSize and MaxSize to DesiredCapacity.
+	MinSize         int
+	MaxSize         int
+	DesiredCapacity int
+}
+
+// InstanceSettings is the instance settings of the stage, with the defaults filled in.
+func (config *TemplateConfig) InstanceSettings() *InstancesConfig {
+	settings := defaultInstances
+	if stageSettings := config.StageConfig().Instances; stageSettings != nil {
+		settings.override(stageSettings)
+	}
+
+	if settings.DesiredCapacity == 0 {
+		settings.DesiredCapacity = settings.MinSize
+	}
+	if settings.MaxSize == 0 {
+		settings.MaxSize = settings.DesiredCapacity
+	}
+	return &settings
+}
+
+func (c *InstancesConfig) override(other *InstancesConfig) {
+	if other.Type != "" {
+		c.Type = other.Type
+	}
+	if other.MinSize != 0 {
+		c.MinSize = other.MinSize
+	}
+	c.MaxSize = other.MaxSize
+	c.DesiredCapacity = other.DesiredCapacity
+}
+
+func (c *InstancesConfig) validate(stageName string, stageConfig *StageConfig) error {
+	if _, known := InstanceTypes[c.Type]; c.Type != "" && !known {
+		return stageConfig.invalidSetting(
+			stageName, "Instances.Type", c.Type, fmt.Sprintf("choose from %s", knownInstanceTypes()),
+		)
+	}
+
+	if c.MinSize < 0 || c.MaxSize < 0 || c.DesiredCapacity < 0 {
+		return stageConfig.invalidSetting(
+			stageName, "Instances", fmt.Sprint(c.MinSize, c.DesiredCapacity, c.MaxSize), "sizes cannot be negative",
+		)
+	}
+
+	settings := defaultInstances
+	settings.override(c)
+	if c.DesiredCapacity != 0 && c.DesiredCapacity < settings.MinSize {
+		return stageConfig.invalidSetting(
+			stageName, "Instances.DesiredCapacity", fmt.Sprint(c.DesiredCapacity), "must be at least MinSize",
+		)
+	}
+	if c.MaxSize != 0 && c.MaxSize < c.DesiredCapacity {
+		return stageConfig.invalidSetting(
+			stageName, "Instances.MaxSize", fmt.Sprint(c.MaxSize), "must be at least DesiredCapacity",
+		)
+	}
+	if c.MaxSize != 0 && c.MaxSize < settings.MinSize {
+		return stageConfig.invalidSetting(stageName, "Instances.MaxSize", fmt.Sprint(c.MaxSize), "must be at least MinSize")
+	}
+
+	return nil
+}
+
+func knownInstanceTypes() []string {
+	var names []string
+	for name := range InstanceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type SitesDoNotFitError struct {
+	Sites        int
+	InstanceType string
+	Instances    int
+	Reason       string
+}
+
+func (err *SitesDoNotFitError) Error() string {
+	return fmt.Sprintf(
+		"%d WordPress sites do not fit on %d %s instances: %s. Use a larger instance type or more instances under "+
+			"Instances",
+		err.Sites, err.Instances, err.InstanceType, err.Reason,
+	)
+}
+
+// A ContainerSize is the CPU units and memory reserved for each container of the sites' tasks.
+type ContainerSize struct {
+	CpuUnits int64
+	MemoryMb int64
+}
+
+// ContainerSize splits the hosts between the sites' tasks. Each task needs to fit on a single host, so the sites are
+// spread evenly over the desired number of hosts and each host is split evenly between the tasks it runs. On Fargate
+// the containers split the task size instead.
+func (config *TemplateConfig) ContainerSize(siteCount int) (*ContainerSize, error) {
+	if config.UsesFargate() {
+		fargate := config.FargateSettings()
+		return &ContainerSize{
+			CpuUnits: int64(fargate.Cpu) / containersPerTask,
+			MemoryMb: int64(fargate.MemoryMb) / containersPerTask,
+		}, nil
+	}
+
+	settings := config.InstanceSettings()
+	instanceType := InstanceTypes[settings.Type]
+	fitError := func(reason string) error {
+		return &SitesDoNotFitError{
+			Sites: siteCount, InstanceType: settings.Type, Instances: settings.DesiredCapacity, Reason: reason,
+		}
+	}
+
+	if settings.DesiredCapacity == 0 {
+		return nil, fitError("there are no instances to run them on")
+	}
+
+	tasksPerInstance := int64((siteCount + settings.DesiredCapacity - 1) / settings.DesiredCapacity)
+	size := &ContainerSize{
+		CpuUnits: instanceType.CpuUnits() / (containersPerTask * tasksPerInstance),
+		MemoryMb: instanceType.EcsMemoryMb() / (containersPerTask * tasksPerInstance),
+	}
+
+	if size.CpuUnits < minContainerCpuUnits {
+		return nil, fitError(fmt.Sprintf(
+			"each container would get %d CPU units, less than the %d needed", size.CpuUnits, minContainerCpuUnits,
+		))
+	}
+	if size.MemoryMb < minContainerMemoryMb {
+		return nil, fitError(fmt.Sprintf(
+			"each container would get %d MB of memory, less than the %d MB needed", size.MemoryMb, minContainerMemoryMb,
+		))
+	}
+
+	return size, nil
+}
+
+// ValidateSiteCapacity checks that the sites fit on the hosts of the stage. Fargate sizes each task on its own.
+func (config *TemplateConfig) ValidateSiteCapacity(sites []*Site) error {
+	if config.UsesFargate() {
+		return nil
+	}
+
+	_, err := config.ContainerSize(len(sites))
+	return err
+}
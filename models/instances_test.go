@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContainerSize(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		instances *InstancesConfig
+		siteCount int
+		expected  ContainerSize
+	}{
+		{"defaults", nil, 1, ContainerSize{CpuUnits: 512, MemoryMb: 416}},
+		{"sites share a host", nil, 3, ContainerSize{CpuUnits: 170, MemoryMb: 138}},
+		{
+			"sites spread over the hosts",
+			&InstancesConfig{Type: "t3.medium", MinSize: 2},
+			5,
+			ContainerSize{CpuUnits: 341, MemoryMb: 618},
+		},
+		{
+			"desired capacity over min size",
+			&InstancesConfig{Type: "m5.large", MinSize: 1, DesiredCapacity: 4, MaxSize: 6},
+			4,
+			ContainerSize{CpuUnits: 1024, MemoryMb: 3776},
+		},
+		{
+			"large hosts",
+			&InstancesConfig{Type: "m5.2xlarge", MinSize: 2},
+			6,
+			ContainerSize{CpuUnits: 1365, MemoryMb: 5098},
+		},
+	} {
+		config := &TemplateConfig{
+			Stage:   &GammaStage,
+			Service: &ServiceConfig{Stages: map[string]*StageConfig{"Gamma": {Instances: test.instances}}},
+		}
+
+		size, err := config.ContainerSize(test.siteCount)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if *size != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, *size)
+		}
+	}
+}
+
+func TestContainerSizeDoesNotFit(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		instances *InstancesConfig
+		siteCount int
+		reason    string
+	}{
+		{"too little memory", nil, 8, "MB of memory"},
+		{"too little CPU", &InstancesConfig{Type: "t2.small"}, 9, "CPU units"},
+	} {
+		config := &TemplateConfig{
+			Stage:   &GammaStage,
+			Service: &ServiceConfig{Stages: map[string]*StageConfig{"Gamma": {Instances: test.instances}}},
+		}
+
+		_, err := config.ContainerSize(test.siteCount)
+		if fitError, ok := err.(*SitesDoNotFitError); !ok || !strings.Contains(fitError.Reason, test.reason) {
+			t.Errorf("%s: expected the sites not to fit for %s, got %v", test.name, test.reason, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+var sslPolicyPrefix = "ELBSecurityPolicy-"
+
+var defaultLoadBalancer = LoadBalancerConfig{
+	SslPolicy:          "ELBSecurityPolicy-TLS-1-2-2017-01",
+	IdleTimeoutSeconds: 30,
+}
+
+// LoadBalancerConfig holds the settings of the load balancer and its HTTPS listener.
+type LoadBalancerConfig struct {
+	// SslPolicy is the security policy of the HTTPS listener, which sets the TLS versions and ciphers clients may use.
+	// Defaults to 'ELBSecurityPolicy-TLS-1-2-2017-01', which only accepts TLS 1.2.
+	SslPolicy string
+	// IdleTimeoutSeconds is how long a connection may stay idle, between 1 and 4000. Defaults to 30.
+	IdleTimeoutSeconds int
+	// DisableHttp2 only serves clients over HTTP/1.1.
+	DisableHttp2 bool
+	// DropInvalidHeaderFields removes the headers whose names are not valid HTTP header names before the request is
+	// passed on.
+	DropInvalidHeaderFields bool
+}
+
+// LoadBalancerSettings is the load balancer settings of the stage, with the defaults filled in.
+func (config *TemplateConfig) LoadBalancerSettings() *LoadBalancerConfig {
+	settings := defaultLoadBalancer
+	if stageSettings := config.StageConfig().LoadBalancer; stageSettings != nil {
+		settings.override(stageSettings)
+	}
+
+	return &settings
+}
+
+func (c *LoadBalancerConfig) override(other *LoadBalancerConfig) {
+	if other.SslPolicy != "" {
+		c.SslPolicy = other.SslPolicy
+	}
+	if other.IdleTimeoutSeconds != 0 {
+		c.IdleTimeoutSeconds = other.IdleTimeoutSeconds
+	}
+	c.DisableHttp2 = other.DisableHttp2
+	c.DropInvalidHeaderFields = other.DropInvalidHeaderFields
+}
+
+func (c *LoadBalancerConfig) validate(stageName string, stageConfig *StageConfig) error {
+	if c.SslPolicy != "" && !strings.HasPrefix(c.SslPolicy, sslPolicyPrefix) {
+		return stageConfig.invalidSetting(
+			stageName, "LoadBalancer.SslPolicy", c.SslPolicy,
+			fmt.Sprintf("must be an ELB security policy e.g. '%s'", defaultLoadBalancer.SslPolicy),
+		)
+	}
+
+	if c.IdleTimeoutSeconds < 0 || c.IdleTimeoutSeconds > 4000 {
+		return stageConfig.invalidSetting(
+			stageName, "LoadBalancer.IdleTimeoutSeconds", fmt.Sprint(c.IdleTimeoutSeconds), "must be between 1 and 4000",
+		)
+	}
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"go.uber.org/zap"
 	"os"
 	"go.uber.org/zap/zapcore"
@@ -9,39 +12,122 @@ import (
 var unsetEnvVar = ""
 var logr *zap.Logger
 var sugaredLogr *zap.SugaredLogger
+var runId = newRunId()
+
+var ConsoleLogFormat = "console"
+var JsonLogFormat = "json"
+
+type InvalidLogSettingError struct {
+	Setting string
+	Value   string
+}
+
+func (err *InvalidLogSettingError) Error() string {
+	return fmt.Sprintf("Invalid log %s: '%s'", err.Setting, err.Value)
+}
+
+// LogSettings control how the application logs. Empty values fall back to the defaults, which depend on whether the
+// DEBUG environment variable is set.
+type LogSettings struct {
+	Level  string
+	Format string
+	File   string
+}
+
+// ConfigureLogger replaces the logger with one built from the settings. It should be called before anything is logged.
+func ConfigureLogger(settings *LogSettings) error {
+	config := defaultLogConfig()
+
+	if settings.Level != "" {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(settings.Level)); err != nil {
+			return &InvalidLogSettingError{Setting: "level", Value: settings.Level}
+		}
+		config.Level = zap.NewAtomicLevelAt(level)
+	}
+
+	switch settings.Format {
+	case "":
+	case JsonLogFormat:
+		config.Encoding = JsonLogFormat
+		config.EncoderConfig = zap.NewProductionEncoderConfig()
+	case ConsoleLogFormat:
+		config.Encoding = ConsoleLogFormat
+	default:
+		return &InvalidLogSettingError{Setting: "format", Value: settings.Format}
+	}
+
+	if settings.File != "" {
+		config.OutputPaths = append(config.OutputPaths, settings.File)
+		config.ErrorOutputPaths = append(config.ErrorOutputPaths, settings.File)
+	}
+
+	return buildLogger(config)
+}
 
 func Logger() *zap.Logger {
 	if logr == nil {
-		if isProduction() {
-			encoderConfig := zapcore.EncoderConfig{
-				// Keys can be anything except the empty string.
-				TimeKey:        "T",
-				MessageKey:     "M",
-				LineEnding:     zapcore.DefaultLineEnding,
-				EncodeTime:     zapcore.ISO8601TimeEncoder,
-				EncodeDuration: zapcore.StringDurationEncoder,
-			}
-			config := zap.Config{
-				Level:            zap.NewAtomicLevelAt(zap.InfoLevel),
-				Development:      false,
-				Encoding:         "console",
-				EncoderConfig:    encoderConfig,
-				OutputPaths:      []string{"stdout"},
-				ErrorOutputPaths: []string{"stderr"},
-			}
-			logr, _ = config.Build()
-		} else {
-			logr, _ = zap.NewDevelopment()
+		if err := buildLogger(defaultLogConfig()); err != nil {
+			panic(err)
 		}
 	}
 
 	return logr
 }
 
+// RunId identifies this invocation of the application. It is attached to every log line so that the AWS calls made by a
+// single run can be found together.
+func RunId() string {
+	return runId
+}
+
+func buildLogger(config zap.Config) error {
+	logger, err := config.Build()
+	if err != nil {
+		return err
+	}
+
+	logr = logger.With(zap.String("RunId", runId))
+	sugaredLogr = nil
+	return nil
+}
+
+func defaultLogConfig() zap.Config {
+	if isProduction() {
+		encoderConfig := zapcore.EncoderConfig{
+			// Keys can be anything except the empty string.
+			TimeKey:        "T",
+			MessageKey:     "M",
+			LineEnding:     zapcore.DefaultLineEnding,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+		}
+		return zap.Config{
+			Level:            zap.NewAtomicLevelAt(zap.InfoLevel),
+			Development:      false,
+			Encoding:         ConsoleLogFormat,
+			EncoderConfig:    encoderConfig,
+			OutputPaths:      []string{"stdout"},
+			ErrorOutputPaths: []string{"stderr"},
+		}
+	}
+
+	return zap.NewDevelopmentConfig()
+}
+
 func isProduction() bool {
 	return os.Getenv("DEBUG") == unsetEnvVar
 }
 
+func newRunId() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(id)
+}
+
 func SugaredLogger() *zap.SugaredLogger {
 	if sugaredLogr == nil {
 		sugaredLogr = Logger().Sugar()
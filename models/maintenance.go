@@ -0,0 +1,38 @@
+package models
+
+import (
+	"fmt"
+)
+
+var MaintenanceOn = "on"
+var MaintenanceOff = "off"
+var MaintenanceModes = []string{MaintenanceOn, MaintenanceOff}
+
+// the load balancer answers with at most 1024 characters in a fixed response
+var maxMaintenanceBodyLength = 1024
+
+var defaultMaintenanceBody = "<!DOCTYPE html><html><head><title>Down for maintenance</title></head>" +
+	"<body><h1>Down for maintenance</h1><p>We will be back shortly.</p></body></html>"
+
+// MaintenanceParamName is the stack parameter that puts the site in maintenance when it is 'on'.
+func MaintenanceParamName(site *Site) string {
+	return fmt.Sprintf("Maintenance%s", site.LogicalId)
+}
+
+// SiteMaintenanceBody is the HTML page answered with while the site is in maintenance.
+func (config *TemplateConfig) SiteMaintenanceBody(site *Site) string {
+	if body := config.SiteConfig(site).MaintenanceBody; body != "" {
+		return body
+	}
+	return defaultMaintenanceBody
+}
+
+func validateMaintenanceBody(siteName string, body string) error {
+	if len(body) > maxMaintenanceBodyLength {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: "MaintenanceBody", Value: fmt.Sprintf("%.20s...", body),
+			Reason: fmt.Sprintf("must be at most %d characters", maxMaintenanceBodyLength),
+		}
+	}
+	return nil
+}
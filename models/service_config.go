@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// ServiceConfig is the contents of the JSON configuration file supplied through the global config file option. All of
+// the values are optional - an empty configuration produces the same stack as before the file existed. Settings that
+// differ between stages are keyed by the stage name e.g. "Gamma".
+type ServiceConfig struct {
+	Owner      string
+	CostCenter string
+	Logging    LogSettings
+	Stages     map[string]*StageConfig
+	// Sites holds the settings of the WordPress sites, keyed by the site name e.g. "blog".
+	Sites map[string]*SiteConfig
+	// ReservedCidrs are ranges that no stage's VPC may overlap e.g. the corporate network the VPCs are peered with.
+	ReservedCidrs []string
+}
+
+func EmptyServiceConfig() *ServiceConfig {
+	return &ServiceConfig{}
+}
+
+// StageConfig returns the settings for the stage, which are empty if the stage is not in the configuration file.
+func (c *ServiceConfig) StageConfig(stage *Stage) *StageConfig {
+	if stageConfig, exists := c.Stages[stage.name]; exists && stageConfig != nil {
+		return stageConfig
+	}
+
+	return &StageConfig{}
+}
+
+// Validate checks the settings that cannot be checked when the file is parsed, so that mistakes are reported before a
+// template is generated.
+func (c *ServiceConfig) Validate() error {
+	for stageName, stageConfig := range c.Stages {
+		if stageConfig == nil {
+			continue
+		}
+		if err := stageConfig.validate(stageName, c.ReservedCidrs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ServiceConfigFromFile(filename string) *ServiceConfig {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to read configuration file '%s': %s", filename, err))
+	}
+
+	config := EmptyServiceConfig()
+	if err := json.Unmarshal(contents, config); err != nil {
+		panic(fmt.Sprintf("Configuration file '%s' is not valid JSON: %s", filename, err))
+	}
+
+	return config
+}
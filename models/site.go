@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+type InvalidSiteNameError struct {
+	Name string
+}
+
+func (err *InvalidSiteNameError) Error() string {
+	return fmt.Sprintf("WordPress site '%s' is not a valid hostname", err.Name)
+}
+
+type DuplicateSiteError struct {
+	Name string
+}
+
+func (err *DuplicateSiteError) Error() string {
+	return fmt.Sprintf("WordPress site '%s' is listed more than once", err.Name)
+}
+
+type SiteCollisionError struct {
+	Name      string
+	OtherName string
+	LogicalId string
+}
+
+func (err *SiteCollisionError) Error() string {
+	return fmt.Sprintf(
+		"WordPress sites '%s' and '%s' both map to the CloudFormation logical id '%s'. Rename one of them",
+		err.Name, err.OtherName, err.LogicalId,
+	)
+}
+
+type UnknownSiteError struct {
+	Name  string
+	Sites []*Site
+}
+
+func (err *UnknownSiteError) Error() string {
+	var names []string
+	for _, site := range err.Sites {
+		names = append(names, site.Name)
+	}
+	return fmt.Sprintf("'%s' is not one of the WordPress sites %s", err.Name, names)
+}
+
+// A Site is a WordPress site served from a subdomain. The name is the hostname as given, which is used in the listener
+// rules, the EFS paths and the environment of the containers. The logical id is derived from the name so that it is safe
+// to use in CloudFormation logical ids e.g. 'my-blog' becomes 'myBlog' and 'shop.eu' becomes 'shopEu'. Names that are
+// already valid logical ids are left untouched so that existing stacks keep their resources.
+type Site struct {
+	Name      string
+	LogicalId string
+}
+
+// TablePrefix is the WordPress database table prefix for the site, which may only contain letters, numbers and
+// underscores.
+func (site *Site) TablePrefix() string {
+	return strings.Map(
+		func(r rune) rune {
+			if isAlphanumeric(r) {
+				return r
+			}
+			return '_'
+		},
+		site.Name,
+	)
+}
+
+func (site *Site) String() string {
+	return site.Name
+}
+
+// SitesFromString parses the separated list of WordPress sites. An error is returned if a site is not a valid hostname,
+// is listed twice or maps onto the same logical id as another site. Logical ids are compared ignoring case so that e.g.
+// 'blog' and 'Blog' are not both deployed.
+func SitesFromString(sites string, separator string) ([]*Site, error) {
+	var parsedSites []*Site
+	sitesByLogicalId := map[string]*Site{}
+
+	for _, name := range strings.Split(sites, separator) {
+		if !hostnamePattern.MatchString(name) {
+			return nil, &InvalidSiteNameError{Name: name}
+		}
+
+		site := &Site{Name: name, LogicalId: logicalIdFromHostname(name)}
+		if other, exists := sitesByLogicalId[strings.ToLower(site.LogicalId)]; exists {
+			if strings.EqualFold(other.Name, site.Name) {
+				return nil, &DuplicateSiteError{Name: site.Name}
+			}
+			return nil, &SiteCollisionError{Name: site.Name, OtherName: other.Name, LogicalId: site.LogicalId}
+		}
+
+		sitesByLogicalId[strings.ToLower(site.LogicalId)] = site
+		parsedSites = append(parsedSites, site)
+	}
+
+	return parsedSites, nil
+}
+
+// logicalIdFromHostname drops the characters that are not allowed in logical ids, capitalizing the letter that follows
+// each of them.
+func logicalIdFromHostname(hostname string) string {
+	var logicalId []rune
+	capitalizeNext := false
+
+	for _, r := range hostname {
+		if !isAlphanumeric(r) {
+			capitalizeNext = true
+			continue
+		}
+
+		if capitalizeNext {
+			r = unicode.ToUpper(r)
+			capitalizeNext = false
+		}
+		logicalId = append(logicalId, r)
+	}
+
+	return string(logicalId)
+}
+
+func isAlphanumeric(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
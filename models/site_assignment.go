@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Sites that do not set their port and listener rule priority get them from a hash of the site name, so that they do
+// not depend on the order of the sites or on which other sites are deployed. The hash picks one of the slots, which
+// maps to a port from the base port and a priority from 1. The priority places each of the site's listener rules in a
+// band of its own. The maintenance rules are checked first, then the configured redirect rules, the forward rules and
+// finally the canonical host redirect rules. The configured redirect band starts after the bands the other rules held
+// before it was added, so that no rule is moved onto a priority another rule still holds while the stack updates.
+var siteSlotCount uint32 = 1000
+var baseSitePort int64 = 9000
+var maxSitePort int64 = 32767 // clear of the ephemeral ports
+var maxSiteRulePriority = int64(siteSlotCount)
+var configuredRedirectRuleBand = 3 * maxSiteRulePriority
+var forwardRuleBand = configuredRedirectRuleBand + int64(maxSiteRedirects)*maxSiteRulePriority
+var canonicalRedirectRuleBand = forwardRuleBand + maxSiteRulePriority
+
+type SiteAssignmentConflictError struct {
+	Site      string
+	OtherSite string
+	Setting   string
+	Value     int64
+}
+
+func (err *SiteAssignmentConflictError) Error() string {
+	return fmt.Sprintf(
+		"WordPress sites '%s' and '%s' are both assigned the %s %d. Set a different %s for one of them under Sites",
+		err.OtherSite, err.Site, err.Setting, err.Value, err.Setting,
+	)
+}
+
+// SitePort is the host port of the site's containers, which the load balancer forwards to.
+func (config *TemplateConfig) SitePort(site *Site) int64 {
+	return config.Service.sitePort(site)
+}
+
+// SiteMaintenanceRulePriority is the priority of the listener rule answering for the site while it is in maintenance.
+func (config *TemplateConfig) SiteMaintenanceRulePriority(site *Site) int64 {
+	return config.Service.siteRulePriority(site)
+}
+
+// SiteConfiguredRedirectRulePriority is the priority of the listener rule of the index'th redirect of the site, which
+// keeps the site's redirects in the order they are given.
+func (config *TemplateConfig) SiteConfiguredRedirectRulePriority(site *Site, index int) int64 {
+	slot := config.Service.siteRulePriority(site) - 1
+	return configuredRedirectRuleBand + slot*int64(maxSiteRedirects) + int64(index) + 1
+}
+
+// SiteRulePriority is the priority of the listener rule forwarding to the site.
+func (config *TemplateConfig) SiteRulePriority(site *Site) int64 {
+	return forwardRuleBand + config.Service.siteRulePriority(site)
+}
+
+// SiteRedirectRulePriority is the priority of the listener rule redirecting to the site's canonical host.
+func (config *TemplateConfig) SiteRedirectRulePriority(site *Site) int64 {
+	return canonicalRedirectRuleBand + config.Service.siteRulePriority(site)
+}
+
+func (c *ServiceConfig) sitePort(site *Site) int64 {
+	if siteConfig, exists := c.Sites[site.Name]; exists && siteConfig != nil && siteConfig.Port != 0 {
+		return siteConfig.Port
+	}
+
+	return baseSitePort + int64(siteSlot(site))
+}
+
+func (c *ServiceConfig) siteRulePriority(site *Site) int64 {
+	if siteConfig, exists := c.Sites[site.Name]; exists && siteConfig != nil && siteConfig.Priority != 0 {
+		return siteConfig.Priority
+	}
+
+	return 1 + int64(siteSlot(site))
+}
+
+func siteSlot(site *Site) uint32 {
+	hash := fnv.New32a()
+	hash.Write([]byte(site.Name))
+	return hash.Sum32() % siteSlotCount
+}
+
+// validateSiteAssignments checks that no two sites share a port or a listener rule priority.
+func (c *ServiceConfig) validateSiteAssignments(sites []*Site) error {
+	for _, assignment := range []struct {
+		setting string
+		value   func(site *Site) int64
+	}{
+		{"Port", c.sitePort},
+		{"Priority", c.siteRulePriority},
+	} {
+		assigned := map[int64]string{}
+		for _, site := range sites {
+			value := assignment.value(site)
+			if other, taken := assigned[value]; taken {
+				return &SiteAssignmentConflictError{
+					Site: site.Name, OtherSite: other, Setting: assignment.setting, Value: value,
+				}
+			}
+			assigned[value] = site.Name
+		}
+	}
+
+	return nil
+}
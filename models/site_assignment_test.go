@@ -0,0 +1,150 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestSiteSlot(t *testing.T) {
+	// the slots must not change, or every existing stack would move its sites to other ports and priorities
+	for _, test := range []struct {
+		name     string
+		expected uint32
+	}{
+		{"blog", 521},
+		{"shop", 857},
+		{"news", 62},
+		{"my-blog", 122},
+		{"shop.eu", 847},
+	} {
+		if slot := siteSlot(&Site{Name: test.name}); slot != test.expected {
+			t.Errorf("%s: expected slot %d, got %d", test.name, test.expected, slot)
+		}
+	}
+}
+
+func TestSiteRulePriorities(t *testing.T) {
+	config := &TemplateConfig{
+		Stage: &GammaStage,
+		Service: &ServiceConfig{Sites: map[string]*SiteConfig{
+			"shop":  {Port: 8080, Priority: 7},
+			"first": {Priority: 1},
+			"last":  {Priority: maxSiteRulePriority},
+		}},
+	}
+
+	for _, test := range []struct {
+		site          string
+		port          int64
+		maintenance   int64
+		firstRedirect int64
+		lastRedirect  int64
+		forward       int64
+		canonical     int64
+	}{
+		{"blog", 9521, 522, 8211, 8220, 13522, 14522},
+		{"shop", 8080, 7, 3061, 3070, 13007, 14007},
+		{"first", 9000 + int64(siteSlot(&Site{Name: "first"})), 1, 3001, 3010, 13001, 14001},
+		{"last", 9000 + int64(siteSlot(&Site{Name: "last"})), 1000, 12991, 13000, 14000, 15000},
+	} {
+		site := &Site{Name: test.site}
+		for _, priority := range []struct {
+			rule     string
+			expected int64
+			actual   int64
+		}{
+			{"port", test.port, config.SitePort(site)},
+			{"maintenance", test.maintenance, config.SiteMaintenanceRulePriority(site)},
+			{"first redirect", test.firstRedirect, config.SiteConfiguredRedirectRulePriority(site, 0)},
+			{"last redirect", test.lastRedirect, config.SiteConfiguredRedirectRulePriority(site, maxSiteRedirects-1)},
+			{"forward", test.forward, config.SiteRulePriority(site)},
+			{"canonical redirect", test.canonical, config.SiteRedirectRulePriority(site)},
+		} {
+			if priority.actual != priority.expected {
+				t.Errorf("%s: expected %s %d, got %d", test.site, priority.rule, priority.expected, priority.actual)
+			}
+		}
+	}
+}
+
+func TestSiteRuleBandsDoNotOverlap(t *testing.T) {
+	for _, band := range []struct {
+		name  string
+		last  int64
+		first int64
+	}{
+		{"maintenance and configured redirect", maxSiteRulePriority, configuredRedirectRuleBand + 1},
+		{
+			"configured redirect and forward",
+			configuredRedirectRuleBand + maxSiteRulePriority*int64(maxSiteRedirects), forwardRuleBand + 1,
+		},
+		{"forward and canonical redirect", forwardRuleBand + maxSiteRulePriority, canonicalRedirectRuleBand + 1},
+	} {
+		if band.last >= band.first {
+			t.Errorf("the %s bands overlap: %d >= %d", band.name, band.last, band.first)
+		}
+	}
+
+	// the load balancer rejects priorities above 50000
+	if last := canonicalRedirectRuleBand + maxSiteRulePriority; last > 50000 {
+		t.Errorf("the last priority %d is above 50000", last)
+	}
+}
+
+func TestValidateSiteAssignments(t *testing.T) {
+	sites := []*Site{{Name: "blog"}, {Name: "shop"}}
+
+	for _, test := range []struct {
+		name    string
+		sites   map[string]*SiteConfig
+		setting string
+	}{
+		{"derived", map[string]*SiteConfig{}, ""},
+		{
+			"configured",
+			map[string]*SiteConfig{"blog": {Port: 8080, Priority: 1}, "shop": {Port: 8081, Priority: 2}},
+			"",
+		},
+		{"shared port", map[string]*SiteConfig{"shop": {Port: 9521}}, "Port"},
+		{"shared priority", map[string]*SiteConfig{"shop": {Priority: 522}}, "Priority"},
+	} {
+		err := (&ServiceConfig{Sites: test.sites}).validateSiteAssignments(sites)
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		conflict, ok := err.(*SiteAssignmentConflictError)
+		if !ok || conflict.Setting != test.setting || conflict.Site != "shop" || conflict.OtherSite != "blog" {
+			t.Errorf("%s: expected a %s conflict between blog and shop, got %v", test.name, test.setting, err)
+		}
+	}
+}
+
+func TestSiteAssignmentRanges(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		siteConfig SiteConfig
+		setting    string
+	}{
+		{"lowest", SiteConfig{Port: 1024, Priority: 1}, ""},
+		{"highest", SiteConfig{Port: maxSitePort, Priority: maxSiteRulePriority}, ""},
+		{"privileged port", SiteConfig{Port: 80}, "Port"},
+		{"ephemeral port", SiteConfig{Port: maxSitePort + 1}, "Port"},
+		{"negative priority", SiteConfig{Priority: -1}, "Priority"},
+		{"priority past the slots", SiteConfig{Priority: maxSiteRulePriority + 1}, "Priority"},
+	} {
+		err := test.siteConfig.validate("blog")
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		if siteError, ok := err.(*InvalidSiteSettingError); !ok || siteError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+var ApexCanonicalHost = "apex"
+var WwwCanonicalHost = "www"
+
+// the host labels are relative to the domain name, the apex being the domain name itself
+var apexHostLabel = ""
+var wwwHostLabel = "www"
+
+// Route 53 hands out hosted zone ids with this prefix, which CloudFormation does not accept
+var hostedZoneIdPrefix = "/hostedzone/"
+
+type InvalidSiteSettingError struct {
+	Site    string
+	Setting string
+	Value   string
+	Reason  string
+}
+
+func (err *InvalidSiteSettingError) Error() string {
+	return fmt.Sprintf("WordPress site '%s' has an invalid %s '%s': %s", err.Site, err.Setting, err.Value, err.Reason)
+}
+
+// SiteConfig holds the settings of a single WordPress site, keyed by the site name in the configuration file. The zero
+// value serves the site at its subdomain only.
+type SiteConfig struct {
+	// Apex also serves the site at the domain name itself.
+	Apex bool
+	// Www also serves the site at the www subdomain.
+	Www bool
+	// CanonicalHost is either 'apex' or 'www'. Requests for the other one are permanently redirected to it, which needs
+	// the site to claim both.
+	CanonicalHost string
+	// DomainName gives the site a domain of its own, which it is served at instead of its subdomain of the stack's
+	// domain name. The apex is always claimed, and Www claims the www subdomain of the site's domain.
+	DomainName string
+	// HostedZoneId is the Route 53 hosted zone of the site's domain, which its alias records are created in.
+	HostedZoneId string
+	// CertificateArn is the certificate for the site's domain. Defaults to the issued certificate requested for the
+	// domain by setup-site-ssl.
+	CertificateArn string
+	// Port is the host port of the site's containers, between 1024 and 32767. Defaults to a port derived from the site
+	// name.
+	Port int64
+	// Priority orders the site's listener rules among those of the other sites, between 1 and 1000. Defaults to a
+	// priority derived from the site name.
+	Priority int64
+	// TargetGroup tunes the health check of the site and how the load balancer hands requests to it.
+	TargetGroup *TargetGroupConfig
+	// MaintenanceBody is the HTML page, of at most 1024 characters, answered with a 503 while the site is in
+	// maintenance. Defaults to a plain 'Down for maintenance' page.
+	MaintenanceBody string
+	// Redirects send the requests for other hosts or paths elsewhere, in the order given, e.g. to keep the URLs of a
+	// retired site working. A site can have up to 10.
+	Redirects []RedirectRuleConfig
+}
+
+// HasOwnDomain is whether the site is served at a domain of its own rather than the stack's domain name.
+func (c *SiteConfig) HasOwnDomain() bool {
+	return c.DomainName != ""
+}
+
+// A SiteRedirect sends every request for one host of a site to another, both given relative to the domain name.
+type SiteRedirect struct {
+	FromLabel string
+	ToLabel   string
+}
+
+// SiteConfig returns the settings for the site, which are empty if the site is not in the configuration file.
+func (config *TemplateConfig) SiteConfig(site *Site) *SiteConfig {
+	if siteConfig, exists := config.Service.Sites[site.Name]; exists && siteConfig != nil {
+		return siteConfig
+	}
+
+	return &SiteConfig{}
+}
+
+// SiteHostLabels are the hosts the site is served at, relative to the domain name. The host that redirects to the
+// canonical host is left out.
+func (config *TemplateConfig) SiteHostLabels(site *Site) []string {
+	siteConfig := config.SiteConfig(site)
+
+	var labels []string
+	if !siteConfig.HasOwnDomain() {
+		labels = append(labels, site.Name)
+	}
+	if siteConfig.claimsApex() && siteConfig.CanonicalHost != WwwCanonicalHost {
+		labels = append(labels, apexHostLabel)
+	}
+	if siteConfig.Www && siteConfig.CanonicalHost != ApexCanonicalHost {
+		labels = append(labels, wwwHostLabel)
+	}
+
+	return labels
+}
+
+// SiteAliasLabels are the hosts that need DNS records pointing at the load balancer, including any that redirect.
+func (config *TemplateConfig) SiteAliasLabels(site *Site) []string {
+	siteConfig := config.SiteConfig(site)
+
+	var labels []string
+	if !siteConfig.HasOwnDomain() {
+		labels = append(labels, site.Name)
+	}
+	if siteConfig.claimsApex() {
+		labels = append(labels, apexHostLabel)
+	}
+	if siteConfig.Www {
+		labels = append(labels, wwwHostLabel)
+	}
+
+	return labels
+}
+
+// SiteRedirect is the canonical host redirect for the site, or nil if it has none.
+func (config *TemplateConfig) SiteRedirect(site *Site) *SiteRedirect {
+	switch config.SiteConfig(site).CanonicalHost {
+	case ApexCanonicalHost:
+		return &SiteRedirect{FromLabel: wwwHostLabel, ToLabel: apexHostLabel}
+	case WwwCanonicalHost:
+		return &SiteRedirect{FromLabel: apexHostLabel, ToLabel: wwwHostLabel}
+	default:
+		return nil
+	}
+}
+
+func (c *SiteConfig) claimsApex() bool {
+	return c.Apex || c.HasOwnDomain()
+}
+
+// Hostname is the full host name of a host label.
+func Hostname(label string, domainName string) string {
+	if label == apexHostLabel {
+		return domainName
+	}
+
+	return fmt.Sprintf("%s.%s", label, domainName)
+}
+
+// HostLabelName names the host label of the site in logical ids, which is empty for the site's subdomain.
+func HostLabelName(site *Site, hostLabel string) string {
+	switch hostLabel {
+	case site.Name:
+		return ""
+	case apexHostLabel:
+		return "Apex"
+	default:
+		return "Www"
+	}
+}
+
+// BareHostedZoneId is the hosted zone id without the '/hostedzone/' prefix.
+func BareHostedZoneId(hostedZoneId string) string {
+	return strings.TrimPrefix(hostedZoneId, hostedZoneIdPrefix)
+}
+
+// ValidateSites checks the site settings against the sites being deployed. No two sites can share a port or a listener
+// rule priority. The apex and www hosts of the stack's domain name can each be claimed by only one site, and not at all
+// if a site is already served from the www subdomain. A domain of a site's own cannot be shared with another site, and
+// neither can a host redirected from.
+func (c *ServiceConfig) ValidateSites(sites []*Site, domainName string) error {
+	claims := map[string]string{}
+	for _, site := range sites {
+		claims[site.Name] = site.Name
+	}
+	ownDomains := map[string]string{}
+
+	for _, site := range sites {
+		siteConfig, exists := c.Sites[site.Name]
+		if !exists || siteConfig == nil {
+			continue
+		}
+
+		if err := siteConfig.validate(site.Name); err != nil {
+			return err
+		}
+
+		if siteConfig.HasOwnDomain() {
+			ownDomain := strings.ToLower(siteConfig.DomainName)
+			if other, claimed := ownDomains[ownDomain]; claimed {
+				return &InvalidSiteSettingError{
+					Site: site.Name, Setting: "DomainName", Value: siteConfig.DomainName,
+					Reason: fmt.Sprintf("the domain is already served by site '%s'", other),
+				}
+			}
+			ownDomains[ownDomain] = site.Name
+			continue
+		}
+
+		for _, claim := range []struct {
+			setting string
+			claimed bool
+			label   string
+		}{
+			{"Apex", siteConfig.Apex, apexHostLabel},
+			{"Www", siteConfig.Www, wwwHostLabel},
+		} {
+			if !claim.claimed {
+				continue
+			}
+			if other, claimed := claims[claim.label]; claimed {
+				return &InvalidSiteSettingError{
+					Site: site.Name, Setting: claim.setting, Value: "true",
+					Reason: fmt.Sprintf("the host is already served by site '%s'", other),
+				}
+			}
+			claims[claim.label] = site.Name
+		}
+	}
+
+	if err := c.validateRedirectHosts(sites, domainName); err != nil {
+		return err
+	}
+
+	return c.validateSiteAssignments(sites)
+}
+
+// validateRedirectHosts checks that a host is only redirected from by a single site, and is not served by another
+// site, which the redirect would take over as the redirects are checked before the sites are forwarded to. A site's own
+// host already has alias records, so a redirect of it cannot add them again. The hosts of the sites under the stack's
+// domain name are only known when the domain name is given.
+func (c *ServiceConfig) validateRedirectHosts(sites []*Site, domainName string) error {
+	config := &TemplateConfig{Service: c}
+
+	siteHosts := map[string]string{}
+	for _, site := range sites {
+		hostDomain := config.SiteConfig(site).DomainName
+		if hostDomain == "" {
+			hostDomain = domainName
+		}
+		if hostDomain == "" {
+			continue
+		}
+
+		for _, label := range config.SiteAliasLabels(site) {
+			siteHosts[strings.ToLower(Hostname(label, hostDomain))] = site.Name
+		}
+	}
+
+	redirectHosts := map[string]string{}
+	for _, site := range sites {
+		for i, redirect := range config.SiteConfig(site).Redirects {
+			host := strings.ToLower(redirect.FromHost)
+			invalidFromHost := func(reason string) error {
+				return &InvalidSiteSettingError{
+					Site: site.Name, Setting: fmt.Sprintf("Redirects[%d].FromHost", i), Value: redirect.FromHost,
+					Reason: reason,
+				}
+			}
+
+			if other, served := siteHosts[host]; served && other != site.Name {
+				return invalidFromHost(fmt.Sprintf("the host is already served by site '%s'", other))
+			} else if served && redirect.HostedZoneId != "" {
+				return invalidFromHost("the site's own alias records already point the host at the load balancer")
+			}
+
+			if other, redirected := redirectHosts[host]; redirected && other != site.Name {
+				return invalidFromHost(fmt.Sprintf("the host is already redirected by site '%s'", other))
+			}
+			redirectHosts[host] = site.Name
+		}
+	}
+
+	return nil
+}
+
+func (c *SiteConfig) validate(siteName string) error {
+	if c.HasOwnDomain() {
+		if !hostnamePattern.MatchString(c.DomainName) {
+			return &InvalidSiteSettingError{
+				Site: siteName, Setting: "DomainName", Value: c.DomainName, Reason: "must be a domain name",
+			}
+		}
+		if c.HostedZoneId == "" {
+			return &InvalidSiteSettingError{
+				Site: siteName, Setting: "HostedZoneId", Value: "",
+				Reason: "is needed for the alias records of the domain",
+			}
+		}
+	}
+
+	if c.Port != 0 && (c.Port < 1024 || c.Port > maxSitePort) {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: "Port", Value: fmt.Sprint(c.Port),
+			Reason: fmt.Sprintf("must be between 1024 and %d", maxSitePort),
+		}
+	}
+	if c.Priority != 0 && (c.Priority < 1 || c.Priority > maxSiteRulePriority) {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: "Priority", Value: fmt.Sprint(c.Priority),
+			Reason: fmt.Sprintf("must be between 1 and %d", maxSiteRulePriority),
+		}
+	}
+
+	if c.TargetGroup != nil {
+		if err := c.TargetGroup.validate(siteName); err != nil {
+			return err
+		}
+	}
+	if err := validateMaintenanceBody(siteName, c.MaintenanceBody); err != nil {
+		return err
+	}
+	if err := c.validateRedirects(siteName); err != nil {
+		return err
+	}
+
+	switch c.CanonicalHost {
+	case "":
+	case ApexCanonicalHost, WwwCanonicalHost:
+		if !c.claimsApex() || !c.Www {
+			return &InvalidSiteSettingError{
+				Site: siteName, Setting: "CanonicalHost", Value: c.CanonicalHost,
+				Reason: "redirecting between the apex and www needs the site to claim both",
+			}
+		}
+	default:
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: "CanonicalHost", Value: c.CanonicalHost,
+			Reason: fmt.Sprintf("choose from %s", []string{ApexCanonicalHost, WwwCanonicalHost}),
+		}
+	}
+
+	return nil
+}
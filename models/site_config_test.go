@@ -0,0 +1,261 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func sitesNamed(names ...string) []*Site {
+	var sites []*Site
+	for _, name := range names {
+		sites = append(sites, &Site{Name: name, LogicalId: logicalIdFromHostname(name)})
+	}
+	return sites
+}
+
+func TestSiteHosts(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		siteConfig *SiteConfig
+		hosts      []string
+		aliases    []string
+		redirect   *SiteRedirect
+	}{
+		{"subdomain", nil, []string{"blog"}, []string{"blog"}, nil},
+		{"apex", &SiteConfig{Apex: true}, []string{"blog", ""}, []string{"blog", ""}, nil},
+		{
+			"www is canonical",
+			&SiteConfig{Apex: true, Www: true, CanonicalHost: WwwCanonicalHost},
+			[]string{"blog", "www"},
+			[]string{"blog", "", "www"},
+			&SiteRedirect{FromLabel: "", ToLabel: "www"},
+		},
+		{
+			"apex is canonical",
+			&SiteConfig{Apex: true, Www: true, CanonicalHost: ApexCanonicalHost},
+			[]string{"blog", ""},
+			[]string{"blog", "", "www"},
+			&SiteRedirect{FromLabel: "www", ToLabel: ""},
+		},
+		{
+			"own domain",
+			&SiteConfig{DomainName: "blog.example.org", Www: true},
+			[]string{"", "www"},
+			[]string{"", "www"},
+			nil,
+		},
+	} {
+		config := &TemplateConfig{Service: &ServiceConfig{Sites: map[string]*SiteConfig{"blog": test.siteConfig}}}
+		site := &Site{Name: "blog", LogicalId: "blog"}
+
+		if hosts := config.SiteHostLabels(site); !reflect.DeepEqual(hosts, test.hosts) {
+			t.Errorf("%s: expected hosts %q, got %q", test.name, test.hosts, hosts)
+		}
+		if aliases := config.SiteAliasLabels(site); !reflect.DeepEqual(aliases, test.aliases) {
+			t.Errorf("%s: expected alias hosts %q, got %q", test.name, test.aliases, aliases)
+		}
+		if redirect := config.SiteRedirect(site); !reflect.DeepEqual(redirect, test.redirect) {
+			t.Errorf("%s: expected redirect %+v, got %+v", test.name, test.redirect, redirect)
+		}
+	}
+}
+
+func TestValidateSites(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		sites      []*Site
+		domainName string
+		configs    map[string]*SiteConfig
+		site       string
+		setting    string
+	}{
+		{name: "no settings", sites: sitesNamed("blog", "shop")},
+		{
+			name:    "apex and www served by different sites",
+			sites:   sitesNamed("blog", "shop"),
+			configs: map[string]*SiteConfig{"blog": {Apex: true}, "shop": {Www: true}},
+		},
+		{
+			name:    "canonical host",
+			sites:   sitesNamed("blog"),
+			configs: map[string]*SiteConfig{"blog": {Apex: true, Www: true, CanonicalHost: WwwCanonicalHost}},
+		},
+		{
+			name:    "apex claimed twice",
+			sites:   sitesNamed("blog", "shop"),
+			configs: map[string]*SiteConfig{"blog": {Apex: true}, "shop": {Apex: true}},
+			site:    "shop",
+			setting: "Apex",
+		},
+		{
+			name:    "www claimed twice",
+			sites:   sitesNamed("blog", "shop"),
+			configs: map[string]*SiteConfig{"blog": {Www: true}, "shop": {Www: true}},
+			site:    "shop",
+			setting: "Www",
+		},
+		{
+			name:    "www served by a site of that name",
+			sites:   sitesNamed("blog", "www"),
+			configs: map[string]*SiteConfig{"blog": {Www: true}},
+			site:    "blog",
+			setting: "Www",
+		},
+		{
+			name:    "canonical host without both hosts",
+			sites:   sitesNamed("blog"),
+			configs: map[string]*SiteConfig{"blog": {Apex: true, CanonicalHost: ApexCanonicalHost}},
+			site:    "blog",
+			setting: "CanonicalHost",
+		},
+		{
+			name:    "unknown canonical host",
+			sites:   sitesNamed("blog"),
+			configs: map[string]*SiteConfig{"blog": {Apex: true, Www: true, CanonicalHost: "root"}},
+			site:    "blog",
+			setting: "CanonicalHost",
+		},
+		{
+			name:  "own domain next to the stack's apex and www",
+			sites: sitesNamed("blog", "shop"),
+			configs: map[string]*SiteConfig{
+				"blog": {DomainName: "blog.example.org", HostedZoneId: "Z1", Www: true},
+				"shop": {Apex: true, Www: true},
+			},
+		},
+		{
+			name:  "canonical host of an own domain",
+			sites: sitesNamed("blog"),
+			configs: map[string]*SiteConfig{
+				"blog": {
+					DomainName: "blog.example.org", HostedZoneId: "Z1", Www: true, CanonicalHost: ApexCanonicalHost,
+				},
+			},
+		},
+		{
+			name:  "own domain served twice",
+			sites: sitesNamed("blog", "shop"),
+			configs: map[string]*SiteConfig{
+				"blog": {DomainName: "example.org", HostedZoneId: "Z1"},
+				"shop": {DomainName: "Example.org", HostedZoneId: "Z1"},
+			},
+			site:    "shop",
+			setting: "DomainName",
+		},
+		{
+			name:    "own domain without a hosted zone",
+			sites:   sitesNamed("blog"),
+			configs: map[string]*SiteConfig{"blog": {DomainName: "example.org"}},
+			site:    "blog",
+			setting: "HostedZoneId",
+		},
+		{
+			name:    "own domain that is not a host name",
+			sites:   sitesNamed("blog"),
+			configs: map[string]*SiteConfig{"blog": {DomainName: "example.org/blog", HostedZoneId: "Z1"}},
+			site:    "blog",
+			setting: "DomainName",
+		},
+		{
+			name:       "redirect from a retired host",
+			sites:      sitesNamed("blog"),
+			domainName: "example.com",
+			configs: map[string]*SiteConfig{"blog": {Redirects: []RedirectRuleConfig{
+				{FromHost: "old-blog.example.com", ToHost: "blog.example.com", HostedZoneId: "Z1"},
+			}}},
+		},
+		{
+			name:       "redirect of a path of the site's own host",
+			sites:      sitesNamed("blog"),
+			domainName: "example.com",
+			configs: map[string]*SiteConfig{"blog": {Redirects: []RedirectRuleConfig{
+				{FromHost: "blog.example.com", FromPath: "/old/*", ToPath: "/new/"},
+			}}},
+		},
+		{
+			name:       "one site redirecting several paths of a host",
+			sites:      sitesNamed("blog"),
+			domainName: "example.com",
+			configs: map[string]*SiteConfig{"blog": {Redirects: []RedirectRuleConfig{
+				{FromHost: "old.example.org", FromPath: "/a", ToHost: "blog.example.com", HostedZoneId: "Z1"},
+				{FromHost: "old.example.org", FromPath: "/b", ToHost: "blog.example.com"},
+			}}},
+		},
+		{
+			name:       "redirect of another site's host",
+			sites:      sitesNamed("blog", "shop"),
+			domainName: "example.com",
+			configs: map[string]*SiteConfig{"blog": {Redirects: []RedirectRuleConfig{
+				{FromHost: "Shop.example.com", ToHost: "blog.example.com"},
+			}}},
+			site:    "blog",
+			setting: "Redirects[0].FromHost",
+		},
+		{
+			name:       "redirect of another site's apex",
+			sites:      sitesNamed("blog", "shop"),
+			domainName: "example.com",
+			configs: map[string]*SiteConfig{
+				"blog": {Redirects: []RedirectRuleConfig{{FromHost: "example.com", ToHost: "blog.example.com"}}},
+				"shop": {Apex: true},
+			},
+			site:    "blog",
+			setting: "Redirects[0].FromHost",
+		},
+		{
+			name:  "redirect of another site's own domain",
+			sites: sitesNamed("blog", "shop"),
+			configs: map[string]*SiteConfig{
+				"blog": {Redirects: []RedirectRuleConfig{
+					{FromHost: "www.shop.example.org", ToHost: "blog.example.com"},
+				}},
+				"shop": {DomainName: "shop.example.org", HostedZoneId: "Z1", Www: true},
+			},
+			site:    "blog",
+			setting: "Redirects[0].FromHost",
+		},
+		{
+			name:  "stack hosts are not checked without the domain name",
+			sites: sitesNamed("blog", "shop"),
+			configs: map[string]*SiteConfig{"blog": {Redirects: []RedirectRuleConfig{
+				{FromHost: "shop.example.com", ToHost: "blog.example.com"},
+			}}},
+		},
+		{
+			name:       "alias records for the site's own host",
+			sites:      sitesNamed("blog"),
+			domainName: "example.com",
+			configs: map[string]*SiteConfig{"blog": {Redirects: []RedirectRuleConfig{
+				{FromHost: "blog.example.com", FromPath: "/old/*", ToPath: "/new/", HostedZoneId: "Z1"},
+			}}},
+			site:    "blog",
+			setting: "Redirects[0].FromHost",
+		},
+		{
+			name:  "host redirected by two sites",
+			sites: sitesNamed("blog", "shop"),
+			configs: map[string]*SiteConfig{
+				"blog": {Redirects: []RedirectRuleConfig{{FromHost: "old.example.org", ToHost: "blog.example.com"}}},
+				"shop": {Redirects: []RedirectRuleConfig{
+					{FromHost: "new.example.org", ToHost: "shop.example.com"},
+					{FromHost: "OLD.example.org", ToHost: "shop.example.com"},
+				}},
+			},
+			site:    "shop",
+			setting: "Redirects[1].FromHost",
+		},
+	} {
+		err := (&ServiceConfig{Sites: test.configs}).ValidateSites(test.sites, test.domainName)
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		siteError, ok := err.(*InvalidSiteSettingError)
+		if !ok || siteError.Site != test.site || siteError.Setting != test.setting {
+			t.Errorf("%s: expected site '%s' to have an invalid %s, got %v", test.name, test.site, test.setting, err)
+		}
+	}
+}
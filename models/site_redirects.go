@@ -0,0 +1,108 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+var maxSiteRedirects = 10
+var defaultRedirectStatusCode = 301
+var redirectStatusCodes = []int{301, 302}
+
+// A RedirectRuleConfig keeps the URLs of a retired or renamed site working by redirecting the requests for a host, or
+// for the paths of a host that match a pattern, to another host or path. The query is always kept.
+type RedirectRuleConfig struct {
+	// FromHost is the host redirected from e.g. 'old.example.com'.
+	FromHost string
+	// FromPath is a path pattern, which may use '*' and '?' e.g. '/blog/*'. Defaults to every path.
+	FromPath string
+	// ToHost is the host redirected to e.g. 'new.example.com'. Defaults to the requested host.
+	ToHost string
+	// ToPath is the path redirected to, which may use '#{path}' for the requested path without its leading slash e.g.
+	// '/archive/#{path}'. Defaults to the requested path.
+	ToPath string
+	// StatusCode is either 301 for a permanent redirect or 302 for a temporary one. Defaults to 301.
+	StatusCode int
+	// HostedZoneId is the Route 53 hosted zone of the host redirected from. Alias records pointing the host at the load
+	// balancer are created in it when given.
+	HostedZoneId string
+	// CertificateArn is a certificate for the host redirected from, which is added to the HTTPS listener when given.
+	CertificateArn string
+}
+
+// RedirectStatusCode is the status code as the load balancer spells it e.g. 'HTTP_301'.
+func (c *RedirectRuleConfig) RedirectStatusCode() string {
+	if c.StatusCode == 0 {
+		return fmt.Sprintf("HTTP_%d", defaultRedirectStatusCode)
+	}
+	return fmt.Sprintf("HTTP_%d", c.StatusCode)
+}
+
+func (c *RedirectRuleConfig) validate(siteName string, index int) error {
+	setting := func(name string) string {
+		return fmt.Sprintf("Redirects[%d].%s", index, name)
+	}
+
+	if !hostnamePattern.MatchString(c.FromHost) {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: setting("FromHost"), Value: c.FromHost, Reason: "must be a host name",
+		}
+	}
+	if c.FromPath != "" && !strings.HasPrefix(c.FromPath, "/") {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: setting("FromPath"), Value: c.FromPath, Reason: "must start with '/'",
+		}
+	}
+
+	if c.ToHost == "" && c.ToPath == "" {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: setting("ToHost"), Value: "",
+			Reason: "either ToHost or ToPath is needed, otherwise the request is redirected to itself",
+		}
+	}
+	if c.ToHost != "" && !hostnamePattern.MatchString(c.ToHost) {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: setting("ToHost"), Value: c.ToHost, Reason: "must be a host name",
+		}
+	}
+	if c.ToPath != "" && !strings.HasPrefix(c.ToPath, "/") {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: setting("ToPath"), Value: c.ToPath, Reason: "must start with '/'",
+		}
+	}
+
+	switch c.StatusCode {
+	case 0, 301, 302:
+	default:
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: setting("StatusCode"), Value: fmt.Sprint(c.StatusCode),
+			Reason: fmt.Sprintf("choose from %v", redirectStatusCodes),
+		}
+	}
+
+	if c.CertificateArn != "" && !strings.HasPrefix(c.CertificateArn, "arn:aws:acm:") {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: setting("CertificateArn"), Value: c.CertificateArn,
+			Reason: "must be an ACM certificate",
+		}
+	}
+
+	return nil
+}
+
+func (c *SiteConfig) validateRedirects(siteName string) error {
+	if len(c.Redirects) > maxSiteRedirects {
+		return &InvalidSiteSettingError{
+			Site: siteName, Setting: "Redirects", Value: fmt.Sprint(len(c.Redirects)),
+			Reason: fmt.Sprintf("a site can have at most %d redirects", maxSiteRedirects),
+		}
+	}
+
+	for i := range c.Redirects {
+		if err := c.Redirects[i].validate(siteName, i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
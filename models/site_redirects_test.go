@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestRedirectRuleConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		redirect RedirectRuleConfig
+		setting  string
+	}{
+		{"host", RedirectRuleConfig{FromHost: "old.example.com", ToHost: "new.example.com"}, ""},
+		{
+			"path",
+			RedirectRuleConfig{FromHost: "blog.example.com", FromPath: "/2017/*", ToPath: "/archive/#{path}"},
+			"",
+		},
+		{
+			"temporary with a certificate",
+			RedirectRuleConfig{
+				FromHost: "old.example.com", ToHost: "new.example.com", StatusCode: 302,
+				CertificateArn: "arn:aws:acm:us-west-2:123456789012:certificate/abc",
+			},
+			"",
+		},
+		{"no host", RedirectRuleConfig{ToHost: "new.example.com"}, "Redirects[3].FromHost"},
+		{"URL as host", RedirectRuleConfig{FromHost: "https://a.com", ToHost: "b.com"}, "Redirects[3].FromHost"},
+		{
+			"relative path",
+			RedirectRuleConfig{FromHost: "a.com", FromPath: "blog/*", ToHost: "b.com"},
+			"Redirects[3].FromPath",
+		},
+		{"redirect to itself", RedirectRuleConfig{FromHost: "a.com", FromPath: "/blog"}, "Redirects[3].ToHost"},
+		{"invalid target host", RedirectRuleConfig{FromHost: "a.com", ToHost: "b.com/blog"}, "Redirects[3].ToHost"},
+		{"relative target path", RedirectRuleConfig{FromHost: "a.com", ToPath: "#{path}"}, "Redirects[3].ToPath"},
+		{
+			"see other",
+			RedirectRuleConfig{FromHost: "a.com", ToHost: "b.com", StatusCode: 303},
+			"Redirects[3].StatusCode",
+		},
+		{
+			"not an ACM certificate",
+			RedirectRuleConfig{FromHost: "a.com", ToHost: "b.com", CertificateArn: "arn:aws:iam::123456789012:cert"},
+			"Redirects[3].CertificateArn",
+		},
+	} {
+		err := test.redirect.validate("blog", 3)
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		if siteError, ok := err.(*InvalidSiteSettingError); !ok || siteError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
+
+func TestValidateRedirectCount(t *testing.T) {
+	redirects := make([]RedirectRuleConfig, maxSiteRedirects+1)
+	for i := range redirects {
+		redirects[i] = RedirectRuleConfig{FromHost: "old.example.com", ToHost: "new.example.com"}
+	}
+
+	if err := (&SiteConfig{Redirects: redirects[:maxSiteRedirects]}).validateRedirects("blog"); err != nil {
+		t.Errorf("unexpected error for %d redirects: %s", maxSiteRedirects, err)
+	}
+
+	err := (&SiteConfig{Redirects: redirects}).validateRedirects("blog")
+	if siteError, ok := err.(*InvalidSiteSettingError); !ok || siteError.Setting != "Redirects" {
+		t.Errorf("expected too many redirects, got %v", err)
+	}
+}
+
+func TestRedirectStatusCode(t *testing.T) {
+	for _, test := range []struct {
+		statusCode int
+		expected   string
+	}{
+		{0, "HTTP_301"},
+		{301, "HTTP_301"},
+		{302, "HTTP_302"},
+	} {
+		if code := (&RedirectRuleConfig{StatusCode: test.statusCode}).RedirectStatusCode(); code != test.expected {
+			t.Errorf("%d: expected %s, got %s", test.statusCode, test.expected, code)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSitesFromString(t *testing.T) {
+	for _, test := range []struct {
+		sites    string
+		expected []*Site
+	}{
+		{"blog", []*Site{{Name: "blog", LogicalId: "blog"}}},
+		{
+			"blog:my-blog:shop.eu",
+			[]*Site{
+				{Name: "blog", LogicalId: "blog"},
+				{Name: "my-blog", LogicalId: "myBlog"},
+				{Name: "shop.eu", LogicalId: "shopEu"},
+			},
+		},
+		{"Blog2", []*Site{{Name: "Blog2", LogicalId: "Blog2"}}},
+		{"a-b-c.d", []*Site{{Name: "a-b-c.d", LogicalId: "aBCD"}}},
+	} {
+		sites, err := SitesFromString(test.sites, ":")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.sites, err)
+			continue
+		}
+		if !reflect.DeepEqual(sites, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.sites, test.expected, sites)
+		}
+	}
+}
+
+func TestSitesFromStringInvalid(t *testing.T) {
+	for _, test := range []struct {
+		sites    string
+		expected error
+	}{
+		{"", &InvalidSiteNameError{Name: ""}},
+		{"blog::shop", &InvalidSiteNameError{Name: ""}},
+		{"-blog", &InvalidSiteNameError{Name: "-blog"}},
+		{"blog-", &InvalidSiteNameError{Name: "blog-"}},
+		{"my_blog", &InvalidSiteNameError{Name: "my_blog"}},
+		{"blog:shop:blog", &DuplicateSiteError{Name: "blog"}},
+		{"blog:Blog", &DuplicateSiteError{Name: "Blog"}},
+		{"my-blog:myBlog", &SiteCollisionError{Name: "myBlog", OtherName: "my-blog", LogicalId: "myBlog"}},
+		{"shop.eu:shop-eu", &SiteCollisionError{Name: "shop-eu", OtherName: "shop.eu", LogicalId: "shopEu"}},
+	} {
+		_, err := SitesFromString(test.sites, ":")
+		if !reflect.DeepEqual(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.sites, test.expected, err)
+		}
+	}
+}
+
+func TestSiteTablePrefix(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		expected string
+	}{
+		{"blog", "blog"},
+		{"my-blog", "my_blog"},
+		{"shop.eu", "shop_eu"},
+	} {
+		if prefix := (&Site{Name: test.name}).TablePrefix(); prefix != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, prefix)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
 
 const serviceStackTemplateFileName = "./wp-service-cf-%s.json"
 const serviceStackName = "wp-system-service"
@@ -27,3 +30,7 @@ func (stackName *StackInfo) StackName() *string {
 func (stackName *StackInfo) TemplateFileName() string {
 	return fmt.Sprintf(stackName.baseFileName, stackName.config.Stage)
 }
+
+func (stackName *StackInfo) StackTags() []*cloudformation.Tag {
+	return stackName.config.StackTags()
+}
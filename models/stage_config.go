@@ -0,0 +1,300 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+var SingleNatGateway = "single"
+var NatGatewayPerAz = "per-az"
+var NoNatGateway = "none"
+
+// S3VpcEndpoint is the gateway endpoint for S3, which is added to the route tables rather than the subnets.
+var S3VpcEndpoint = "s3"
+
+// noNatGatewayVpcEndpoints are the endpoints a stage without NAT gateways cannot do without.
+var noNatGatewayVpcEndpoints = []string{"ecs", "ecr", "logs", S3VpcEndpoint}
+
+// InterfaceVpcEndpoints are the names of the interface endpoints that can be configured, along with the AWS services
+// that are needed for each.
+var InterfaceVpcEndpoints = map[string][]string{
+	"ecs":            {"ecs", "ecs-agent", "ecs-telemetry"},
+	"ecr":            {"ecr.api", "ecr.dkr"},
+	"logs":           {"logs"},
+	"ssm":            {"ssm", "ssmmessages", "ec2messages"},
+	"secretsmanager": {"secretsmanager"},
+}
+
+type InvalidStageSettingError struct {
+	Stage   string
+	Setting string
+	Value   string
+	Reason  string
+}
+
+func (err *InvalidStageSettingError) Error() string {
+	return fmt.Sprintf("Stage '%s' has an invalid %s '%s': %s", err.Stage, err.Setting, err.Value, err.Reason)
+}
+
+// StageConfig holds the settings that may differ between stages. The zero value is the default for every setting.
+type StageConfig struct {
+	// PrivateSubnets puts the ECS hosts and EFS mount targets in private subnets, which reach the internet through NAT
+	// gateways. The load balancer stays in the public subnets.
+	PrivateSubnets bool
+	// NatGateways is either 'single', 'per-az' or 'none'. Defaults to one per availability zone for Prod and a single one
+	// for the other stages. Without NAT gateways the private subnets rely on the VPC endpoints to reach AWS.
+	NatGateways string
+	// VpcCidr is the IPv4 range of the VPC. Defaults to 10.0.0.0/16.
+	VpcCidr string
+	// SubnetCount is the number of subnets in each tier. Defaults to 3.
+	SubnetCount int
+	// AzCount is the number of availability zones the subnets are spread over. Defaults to every zone in the region.
+	AzCount int
+	// PublicSubnetPrefix and PrivateSubnetPrefix are the prefix sizes of the subnets in each tier. Default to /24.
+	PublicSubnetPrefix  int
+	PrivateSubnetPrefix int
+	// DualStack adds an Amazon provided IPv6 block to the VPC, a /64 to each subnet and serves the sites over IPv6 as
+	// well as IPv4.
+	DualStack bool
+	// VpcEndpoints are the names of the VPC endpoints to create e.g. 's3', 'ecs', 'ecr', 'logs', 'ssm' and
+	// 'secretsmanager'.
+	VpcEndpoints []string
+	// ExistingVpc deploys the stage into a VPC managed outside of the stack instead of creating one.
+	ExistingVpc *ExistingVpcConfig
+	// AdminCidrs are the IPv4 ranges allowed to SSH into the hosts. Nothing can SSH into the hosts if there are none.
+	AdminCidrs []string
+	// SessionManager launches the hosts without a key pair. They are reached through Systems Manager Session Manager
+	// instead of SSH.
+	SessionManager bool
+	// FlowLogs records the IP traffic in the VPC when set.
+	FlowLogs *FlowLogsConfig
+	// NetworkAcls adds a network ACL to each subnet tier. The public subnets only accept HTTP and HTTPS from the
+	// internet, along with the return traffic of connections made from inside the VPC.
+	NetworkAcls bool
+	// AccessLogs records the requests the load balancer receives when set.
+	AccessLogs *AccessLogsConfig
+	// Waf filters the requests to the load balancer through a web ACL when set.
+	Waf *WafConfig
+	// CloudFront serves the sites through a CloudFront distribution in front of the load balancer when set.
+	CloudFront *CloudFrontConfig
+	// LoadBalancer holds the TLS and HTTP settings of the load balancer.
+	LoadBalancer *LoadBalancerConfig
+	// AccessPolicy only lets signed in visitors or the allowed IP ranges reach the sites when set, which keeps
+	// non-production stages out of search engines.
+	AccessPolicy *AccessPolicyConfig
+	// Instances holds the instance type and the number of ECS hosts, which the tasks of the sites are sized against.
+	Instances *InstancesConfig
+	// Fargate runs the sites on Fargate instead of ECS hosts when set.
+	Fargate *FargateConfig
+	// Images are the container images of the sites, which have to come from ECR without NAT gateways.
+	Images *ImagesConfig
+}
+
+// HasNatGateways is whether the private subnets reach the internet through NAT gateways.
+func (config *TemplateConfig) HasNatGateways() bool {
+	return config.StageConfig().NatGateways != NoNatGateway
+}
+
+// UsesKeyPair is whether the hosts are launched with a key pair, rather than being reached through Session Manager.
+// There are no hosts on Fargate.
+func (config *TemplateConfig) UsesKeyPair() bool {
+	return !config.StageConfig().SessionManager && !config.UsesFargate()
+}
+
+// NatGatewayPerAz is whether each availability zone gets its own NAT gateway, so that the private subnets do not lose
+// internet access when a single zone fails.
+func (config *TemplateConfig) NatGatewayPerAz() bool {
+	switch config.StageConfig().NatGateways {
+	case SingleNatGateway:
+		return false
+	case NatGatewayPerAz:
+		return true
+	default:
+		return *config.Stage == ProdStage
+	}
+}
+
+// ValidateStage checks the settings of the stage being deployed. A stage missing from the configuration file is checked
+// with the defaults, so that its VPC range is still held against the reserved ranges.
+func (config *TemplateConfig) ValidateStage() error {
+	return config.StageConfig().validate(config.Stage.name, config.Service.ReservedCidrs)
+}
+
+// ValidateAzCount checks that the region has as many availability zones as the stage spreads its subnets over. Without
+// an AzCount the subnets are spread over every zone of the region, which then needs one zone for each of them.
+func (config *TemplateConfig) ValidateAzCount(regionAzCount int) error {
+	stageConfig := config.StageConfig()
+	if stageConfig.ExistingVpc != nil {
+		return nil
+	}
+
+	if stageConfig.AzCount > regionAzCount {
+		return stageConfig.invalidSetting(
+			config.Stage.name, "AzCount", fmt.Sprint(stageConfig.AzCount),
+			fmt.Sprintf("region '%s' only has %d availability zones", config.Region, regionAzCount),
+		)
+	}
+	if stageConfig.AzCount == 0 && stageConfig.subnetCount() > regionAzCount {
+		return stageConfig.invalidSetting(
+			config.Stage.name, "SubnetCount", fmt.Sprint(stageConfig.subnetCount()),
+			fmt.Sprintf(
+				"region '%s' only has %d availability zones, and %s", config.Region, regionAzCount, subnetPerAzReason,
+			),
+		)
+	}
+
+	return nil
+}
+
+func (c *StageConfig) validate(stageName string, reservedCidrs []string) error {
+	if err := c.validateAdminAccess(stageName); err != nil {
+		return err
+	}
+
+	if c.AccessLogs != nil {
+		if err := c.AccessLogs.validate(stageName, c); err != nil {
+			return err
+		}
+	}
+
+	if c.Waf != nil {
+		if err := c.Waf.validate(stageName, c); err != nil {
+			return err
+		}
+	}
+
+	if c.CloudFront != nil {
+		if err := c.CloudFront.validate(stageName, c); err != nil {
+			return err
+		}
+	}
+
+	if c.LoadBalancer != nil {
+		if err := c.LoadBalancer.validate(stageName, c); err != nil {
+			return err
+		}
+	}
+
+	if c.AccessPolicy != nil {
+		if err := c.AccessPolicy.validate(stageName, c); err != nil {
+			return err
+		}
+	}
+
+	if c.Instances != nil {
+		if err := c.Instances.validate(stageName, c); err != nil {
+			return err
+		}
+	}
+
+	if c.Fargate != nil {
+		if err := c.Fargate.validate(stageName, c); err != nil {
+			return err
+		}
+	}
+
+	if c.ExistingVpc != nil {
+		return c.ExistingVpc.validate(stageName, c)
+	}
+
+	if c.FlowLogs != nil {
+		if err := c.FlowLogs.validate(stageName, c); err != nil {
+			return err
+		}
+	}
+
+	switch c.NatGateways {
+	case "", SingleNatGateway, NatGatewayPerAz, NoNatGateway:
+	default:
+		return &InvalidStageSettingError{
+			Stage:   stageName,
+			Setting: "NatGateways",
+			Value:   c.NatGateways,
+			Reason:  fmt.Sprintf("choose from %s", []string{SingleNatGateway, NatGatewayPerAz, NoNatGateway}),
+		}
+	}
+
+	for _, endpoint := range c.VpcEndpoints {
+		if _, exists := InterfaceVpcEndpoints[endpoint]; !exists && endpoint != S3VpcEndpoint {
+			return c.invalidSetting(
+				stageName, "VpcEndpoints", endpoint, fmt.Sprintf("choose from %s", vpcEndpointNames()),
+			)
+		}
+	}
+
+	if c.NatGateways == NoNatGateway {
+		if err := c.validateNoNatGateway(stageName); err != nil {
+			return err
+		}
+	}
+
+	if c.AzCount < 0 {
+		return c.invalidSetting(stageName, "AzCount", fmt.Sprint(c.AzCount), "must not be negative")
+	}
+
+	if _, err := c.subnetLayout(stageName); err != nil {
+		return err
+	}
+
+	return c.validateReservedCidrs(stageName, reservedCidrs)
+}
+
+// validateNoNatGateway checks that a stage without NAT gateways can still reach what the hosts and tasks need. The
+// endpoints let them register with ECS, pull images from ECR, whose layers are served out of S3, and ship their logs,
+// while Docker Hub is out of reach, so the images have to be mirrored into ECR.
+func (c *StageConfig) validateNoNatGateway(stageName string) error {
+	if !c.PrivateSubnets {
+		return c.invalidSetting(stageName, "NatGateways", c.NatGateways, "only applies to private subnets")
+	}
+
+	for _, endpoint := range noNatGatewayVpcEndpoints {
+		if !c.hasVpcEndpoint(endpoint) {
+			return c.invalidSetting(
+				stageName, "VpcEndpoints", fmt.Sprint(c.VpcEndpoints),
+				fmt.Sprintf("needs %s without NAT gateways", noNatGatewayVpcEndpoints),
+			)
+		}
+	}
+
+	images := c.Images
+	if images == nil {
+		images = &ImagesConfig{}
+	}
+	return images.validateFromEcr(stageName, c)
+}
+
+func (c *StageConfig) hasVpcEndpoint(name string) bool {
+	for _, endpoint := range c.VpcEndpoints {
+		if endpoint == name {
+			return true
+		}
+	}
+	return false
+}
+
+func vpcEndpointNames() []string {
+	names := []string{S3VpcEndpoint}
+	for name := range InterfaceVpcEndpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (c *StageConfig) validateAdminAccess(stageName string) error {
+	for _, adminCidr := range c.AdminCidrs {
+		if _, adminNet, err := net.ParseCIDR(adminCidr); err != nil || adminNet.IP.To4() == nil {
+			return c.invalidSetting(stageName, "AdminCidrs", adminCidr, "must be an IPv4 CIDR block")
+		}
+	}
+
+	if c.SessionManager && len(c.AdminCidrs) > 0 {
+		return c.invalidSetting(
+			stageName, "AdminCidrs", fmt.Sprint(c.AdminCidrs), "the hosts have no key pair in session manager mode",
+		)
+	}
+
+	return nil
+}
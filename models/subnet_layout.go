@@ -0,0 +1,221 @@
+package models
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+var defaultVpcCidr = "10.0.0.0/16"
+var defaultSubnetCount = 3
+var defaultSubnetPrefix = 24
+
+// the smallest and largest networks AWS permits for VPCs and subnets
+var minNetworkPrefix = 16
+var maxNetworkPrefix = 28
+
+// the load balancer needs subnets in at least two availability zones
+var minSubnetCount = 2
+var minSubnetCountReason = "the load balancer needs subnets in as many availability zones"
+
+// no two subnets of a tier can share an availability zone
+var subnetPerAzReason = "the load balancer and the EFS mount targets take one subnet per availability zone"
+
+// A SubnetLayout is the VPC range and the subnet ranges carved out of it. The public subnets are carved first, from the
+// start of the VPC range, followed by the private subnets. Each subnet is aligned to its own size so that tiers with
+// different prefix sizes never overlap.
+type SubnetLayout struct {
+	VpcCidr      string
+	PublicCidrs  []string
+	PrivateCidrs []string
+}
+
+// SubnetLayout carves the subnets for the stage. The configuration is validated before the template is generated, so
+// an error here is a bug.
+func (config *TemplateConfig) SubnetLayout() *SubnetLayout {
+	layout, err := config.StageConfig().subnetLayout(config.Stage.String())
+	if err != nil {
+		panic(err)
+	}
+
+	return layout
+}
+
+func (c *StageConfig) vpcCidr() string {
+	if c.VpcCidr == "" {
+		return defaultVpcCidr
+	}
+	return c.VpcCidr
+}
+
+func (c *StageConfig) subnetCount() int {
+	if c.SubnetCount == 0 {
+		return defaultSubnetCount
+	}
+	return c.SubnetCount
+}
+
+func (c *StageConfig) publicSubnetPrefix() int {
+	if c.PublicSubnetPrefix == 0 {
+		return defaultSubnetPrefix
+	}
+	return c.PublicSubnetPrefix
+}
+
+func (c *StageConfig) privateSubnetPrefix() int {
+	if c.PrivateSubnetPrefix == 0 {
+		return defaultSubnetPrefix
+	}
+	return c.PrivateSubnetPrefix
+}
+
+func (c *StageConfig) subnetLayout(stageName string) (*SubnetLayout, error) {
+	_, vpcNet, err := net.ParseCIDR(c.vpcCidr())
+	if err != nil || vpcNet.IP.To4() == nil {
+		return nil, c.invalidSetting(stageName, "VpcCidr", c.vpcCidr(), "must be an IPv4 CIDR block")
+	}
+
+	vpcPrefix, _ := vpcNet.Mask.Size()
+	if vpcPrefix < minNetworkPrefix || vpcPrefix > maxNetworkPrefix {
+		return nil, c.invalidSetting(
+			stageName, "VpcCidr", c.vpcCidr(),
+			fmt.Sprintf("the prefix must be between /%d and /%d", minNetworkPrefix, maxNetworkPrefix),
+		)
+	}
+
+	if c.subnetCount() < minSubnetCount {
+		return nil, c.invalidSetting(
+			stageName, "SubnetCount", fmt.Sprint(c.SubnetCount),
+			fmt.Sprintf("must be at least %d, as %s", minSubnetCount, minSubnetCountReason),
+		)
+	}
+	if c.AzCount != 0 && c.AzCount < minSubnetCount {
+		return nil, c.invalidSetting(
+			stageName, "AzCount", fmt.Sprint(c.AzCount),
+			fmt.Sprintf("must be at least %d, as %s", minSubnetCount, minSubnetCountReason),
+		)
+	}
+	if c.AzCount != 0 && c.subnetCount() > c.AzCount {
+		return nil, c.invalidSetting(
+			stageName, "SubnetCount", fmt.Sprint(c.subnetCount()),
+			fmt.Sprintf("must be at most the AzCount of %d, as %s", c.AzCount, subnetPerAzReason),
+		)
+	}
+
+	allocator := &cidrAllocator{vpcNet: vpcNet, next: ipv4ToUint(vpcNet.IP)}
+	layout := &SubnetLayout{VpcCidr: vpcNet.String()}
+
+	layout.PublicCidrs, err = c.carveTier(stageName, allocator, "PublicSubnetPrefix", c.publicSubnetPrefix(), vpcPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.PrivateSubnets {
+		layout.PrivateCidrs, err = c.carveTier(
+			stageName, allocator, "PrivateSubnetPrefix", c.privateSubnetPrefix(), vpcPrefix,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return layout, nil
+}
+
+func (c *StageConfig) carveTier(
+	stageName string, allocator *cidrAllocator, setting string, prefix int, vpcPrefix int,
+) ([]string, error) {
+	if prefix < vpcPrefix || prefix < minNetworkPrefix || prefix > maxNetworkPrefix {
+		return nil, c.invalidSetting(
+			stageName, setting, fmt.Sprint(prefix),
+			fmt.Sprintf("must be between /%d and /%d", maxInt(vpcPrefix, minNetworkPrefix), maxNetworkPrefix),
+		)
+	}
+
+	var cidrs []string
+	for i := 0; i < c.subnetCount(); i++ {
+		cidr, fits := allocator.allocate(prefix)
+		if !fits {
+			return nil, c.invalidSetting(
+				stageName, "VpcCidr", c.vpcCidr(),
+				fmt.Sprintf("too small for %d subnets per tier with the configured prefix sizes", c.subnetCount()),
+			)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+
+	return cidrs, nil
+}
+
+// validateReservedCidrs checks that the VPC does not overlap any of the reserved ranges e.g. the corporate network it
+// will be peered with.
+func (c *StageConfig) validateReservedCidrs(stageName string, reservedCidrs []string) error {
+	_, vpcNet, err := net.ParseCIDR(c.vpcCidr())
+	if err != nil {
+		return c.invalidSetting(stageName, "VpcCidr", c.vpcCidr(), "must be an IPv4 CIDR block")
+	}
+
+	for _, reservedCidr := range reservedCidrs {
+		_, reservedNet, err := net.ParseCIDR(reservedCidr)
+		if err != nil {
+			return &InvalidReservedCidrError{Cidr: reservedCidr}
+		}
+
+		if vpcNet.Contains(reservedNet.IP) || reservedNet.Contains(vpcNet.IP) {
+			return c.invalidSetting(
+				stageName, "VpcCidr", c.vpcCidr(), fmt.Sprintf("overlaps the reserved range '%s'", reservedCidr),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (c *StageConfig) invalidSetting(stageName string, setting string, value string, reason string) error {
+	return &InvalidStageSettingError{Stage: stageName, Setting: setting, Value: value, Reason: reason}
+}
+
+type InvalidReservedCidrError struct {
+	Cidr string
+}
+
+func (err *InvalidReservedCidrError) Error() string {
+	return fmt.Sprintf("Reserved range '%s' is not a CIDR block", err.Cidr)
+}
+
+// cidrAllocator hands out consecutive, non-overlapping subnets of the VPC range.
+type cidrAllocator struct {
+	vpcNet *net.IPNet
+	next   uint64
+}
+
+func (a *cidrAllocator) allocate(prefix int) (string, bool) {
+	vpcPrefix, _ := a.vpcNet.Mask.Size()
+	vpcEnd := ipv4ToUint(a.vpcNet.IP) + uint64(1)<<uint(32-vpcPrefix)
+	size := uint64(1) << uint(32-prefix)
+
+	start := (a.next + size - 1) / size * size
+	if start+size > vpcEnd {
+		return "", false
+	}
+
+	a.next = start + size
+	return fmt.Sprintf("%s/%d", uintToIpv4(start), prefix), true
+}
+
+func ipv4ToUint(ip net.IP) uint64 {
+	return uint64(binary.BigEndian.Uint32(ip.To4()))
+}
+
+func uintToIpv4(value uint64) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, uint32(value))
+	return ip
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestSubnetLayout(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		stageConfig StageConfig
+		expected    SubnetLayout
+	}{
+		{
+			name:        "defaults",
+			stageConfig: StageConfig{},
+			expected: SubnetLayout{
+				VpcCidr:     "10.0.0.0/16",
+				PublicCidrs: []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"},
+			},
+		},
+		{
+			name:        "private subnets follow the public subnets",
+			stageConfig: StageConfig{PrivateSubnets: true},
+			expected: SubnetLayout{
+				VpcCidr:      "10.0.0.0/16",
+				PublicCidrs:  []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"},
+				PrivateCidrs: []string{"10.0.3.0/24", "10.0.4.0/24", "10.0.5.0/24"},
+			},
+		},
+		{
+			name: "larger private subnets are aligned to their size",
+			stageConfig: StageConfig{
+				PrivateSubnets: true, VpcCidr: "172.16.0.0/20", PublicSubnetPrefix: 26, PrivateSubnetPrefix: 24,
+			},
+			expected: SubnetLayout{
+				VpcCidr:      "172.16.0.0/20",
+				PublicCidrs:  []string{"172.16.0.0/26", "172.16.0.64/26", "172.16.0.128/26"},
+				PrivateCidrs: []string{"172.16.1.0/24", "172.16.2.0/24", "172.16.3.0/24"},
+			},
+		},
+		{
+			name:        "the VPC range is normalized",
+			stageConfig: StageConfig{VpcCidr: "10.1.2.3/16", SubnetCount: 2},
+			expected: SubnetLayout{
+				VpcCidr:     "10.1.0.0/16",
+				PublicCidrs: []string{"10.1.0.0/24", "10.1.1.0/24"},
+			},
+		},
+	} {
+		layout, err := test.stageConfig.subnetLayout("Gamma")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*layout, test.expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, *layout)
+		}
+		if err := checkNoOverlap(append(layout.PublicCidrs, layout.PrivateCidrs...)); err != "" {
+			t.Errorf("%s: %s", test.name, err)
+		}
+	}
+}
+
+func TestSubnetLayoutInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		stageConfig StageConfig
+		setting     string
+	}{
+		{"IPv6 range", StageConfig{VpcCidr: "fd00::/56"}, "VpcCidr"},
+		{"not a range", StageConfig{VpcCidr: "10.0.0.0"}, "VpcCidr"},
+		{"VPC range too large", StageConfig{VpcCidr: "10.0.0.0/8"}, "VpcCidr"},
+		{"VPC range too small", StageConfig{VpcCidr: "10.0.0.0/29"}, "VpcCidr"},
+		{"negative subnet count", StageConfig{SubnetCount: -1}, "SubnetCount"},
+		{"a single subnet", StageConfig{SubnetCount: 1}, "SubnetCount"},
+		{"a single availability zone", StageConfig{AzCount: 1, SubnetCount: 2}, "AzCount"},
+		{"more subnets than availability zones", StageConfig{AzCount: 2}, "SubnetCount"},
+		{"subnets larger than the VPC", StageConfig{VpcCidr: "10.0.0.0/26"}, "PublicSubnetPrefix"},
+		{"subnets smaller than AWS allows", StageConfig{PublicSubnetPrefix: 29}, "PublicSubnetPrefix"},
+		{
+			"private subnets larger than the VPC",
+			StageConfig{PrivateSubnets: true, VpcCidr: "10.0.0.0/25", PublicSubnetPrefix: 28},
+			"PrivateSubnetPrefix",
+		},
+		{
+			"subnets do not fit",
+			StageConfig{PrivateSubnets: true, VpcCidr: "10.0.0.0/26", PublicSubnetPrefix: 28, PrivateSubnetPrefix: 28},
+			"VpcCidr",
+		},
+	} {
+		_, err := test.stageConfig.subnetLayout("Gamma")
+		if settingError, ok := err.(*InvalidStageSettingError); !ok || settingError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
+
+func TestValidateAzCount(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		stageConfig *StageConfig
+		setting     string
+	}{
+		{"a subnet in every zone", &StageConfig{}, ""},
+		{"fewer subnets than zones", &StageConfig{SubnetCount: 2}, ""},
+		{"some of the zones", &StageConfig{AzCount: 2, SubnetCount: 2}, ""},
+		{"more zones than the region has", &StageConfig{AzCount: 4}, "AzCount"},
+		{"more subnets than the region has zones", &StageConfig{SubnetCount: 4}, "SubnetCount"},
+		{"existing VPC", &StageConfig{ExistingVpc: &ExistingVpcConfig{}, SubnetCount: 4}, ""},
+	} {
+		config := &TemplateConfig{
+			Stage:   &GammaStage,
+			Region:  &UsWest2,
+			Service: &ServiceConfig{Stages: map[string]*StageConfig{"Gamma": test.stageConfig}},
+		}
+
+		err := config.ValidateAzCount(3)
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		if settingError, ok := err.(*InvalidStageSettingError); !ok || settingError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
+
+func TestCidrAllocator(t *testing.T) {
+	_, vpcNet, _ := net.ParseCIDR("10.0.0.0/24")
+	allocator := &cidrAllocator{vpcNet: vpcNet, next: ipv4ToUint(vpcNet.IP)}
+
+	for _, test := range []struct {
+		prefix   int
+		expected string
+		fits     bool
+	}{
+		{28, "10.0.0.0/28", true},
+		{26, "10.0.0.64/26", true},
+		{28, "10.0.0.128/28", true},
+		{25, "", false},
+		{27, "10.0.0.160/27", true},
+		{26, "10.0.0.192/26", true},
+		{28, "", false},
+	} {
+		cidr, fits := allocator.allocate(test.prefix)
+		if cidr != test.expected || fits != test.fits {
+			t.Errorf("/%d: expected %q %t, got %q %t", test.prefix, test.expected, test.fits, cidr, fits)
+		}
+	}
+}
+
+func checkNoOverlap(cidrs []string) string {
+	for i, cidr := range cidrs {
+		_, ipNet, _ := net.ParseCIDR(cidr)
+		for _, otherCidr := range cidrs[i+1:] {
+			_, otherNet, _ := net.ParseCIDR(otherCidr)
+			if ipNet.Contains(otherNet.IP) || otherNet.Contains(ipNet.IP) {
+				return "subnets " + cidr + " and " + otherCidr + " overlap"
+			}
+		}
+	}
+
+	return ""
+}
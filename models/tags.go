@@ -0,0 +1,71 @@
+package models
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+var stackNameTagKey = "StackName"
+var stageTagKey = "Stage"
+var ownerTagKey = "Owner"
+var costCenterTagKey = "CostCenter"
+var siteTagKey = "Site"
+
+// A Tag is a key value pair applied to the stack and to every taggable resource in it, so that cost reports can be
+// broken down by stage, owner and site.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Tags returns the tags shared by the whole stack. Tags with empty values are left out.
+func (config *TemplateConfig) Tags() []Tag {
+	var tags []Tag
+	for _, tag := range []Tag{
+		{stageTagKey, config.Stage.String()},
+		{ownerTagKey, config.Service.Owner},
+		{costCenterTagKey, config.Service.CostCenter},
+	} {
+		if tag.Value != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// SiteTags returns the stack tags along with the name of the WordPress site the resource belongs to.
+func (config *TemplateConfig) SiteTags(site string) []Tag {
+	return append(config.Tags(), Tag{siteTagKey, site})
+}
+
+// ResourceTags returns the stack tags for a resource in the template.
+func (config *TemplateConfig) ResourceTags() []ResourceTag {
+	return cfResourceTags(config.Tags())
+}
+
+// SiteResourceTags returns the site tags for a resource in the template that belongs to a single WordPress site.
+func (config *TemplateConfig) SiteResourceTags(site string) []ResourceTag {
+	return cfResourceTags(config.SiteTags(site))
+}
+
+// StackTags returns the tags applied to the stack on creation and update. CloudFormation propagates these to the
+// resources it creates as well.
+func (config *TemplateConfig) StackTags() []*cloudformation.Tag {
+	var stackTags []*cloudformation.Tag
+	for _, tag := range config.Tags() {
+		key, value := tag.Key, tag.Value
+		stackTags = append(stackTags, &cloudformation.Tag{Key: &key, Value: &value})
+	}
+
+	return stackTags
+}
+
+func cfResourceTags(tags []Tag) []ResourceTag {
+	resourceTags := []ResourceTag{{Key: String(stackNameTagKey), Value: Ref("AWS::StackName").String()}}
+	for _, tag := range tags {
+		resourceTags = append(resourceTags, ResourceTag{Key: String(tag.Key), Value: String(tag.Value)})
+	}
+
+	return resourceTags
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// the HTTP codes or ranges of codes a healthy site answers with e.g. '200,301,302' or '200-399'
+var healthCheckMatcherPattern = regexp.MustCompile(`^[1-5][0-9]{2}(-[1-5][0-9]{2})?(,[1-5][0-9]{2}(-[1-5][0-9]{2})?)*$`)
+
+var defaultTargetGroup = TargetGroupConfig{
+	HealthCheckPath:            "/",
+	HealthCheckMatcher:         "200,301,302",
+	HealthCheckIntervalSeconds: 10,
+	HealthCheckTimeoutSeconds:  5,
+	HealthyThreshold:           2,
+	UnhealthyThreshold:         2,
+	DeregistrationDelaySeconds: 30,
+}
+
+// TargetGroupConfig tunes how the load balancer checks the health of a site and hands requests to it. Every setting
+// is optional, zero meaning the default.
+type TargetGroupConfig struct {
+	// HealthCheckPath defaults to '/'. Sites that redirect to a setup page or sit behind a login are better checked on a
+	// path that answers directly e.g. '/wp-includes/images/blank.gif'.
+	HealthCheckPath string
+	// HealthCheckMatcher are the HTTP codes of a healthy site. Defaults to '200,301,302'.
+	HealthCheckMatcher string
+	// HealthCheckIntervalSeconds is between 5 and 300. Defaults to 10.
+	HealthCheckIntervalSeconds int
+	// HealthCheckTimeoutSeconds is between 2 and 120, and less than the interval. Defaults to 5.
+	HealthCheckTimeoutSeconds int
+	// HealthyThreshold and UnhealthyThreshold are the number of checks in a row that change the health of the site,
+	// between 2 and 10. Both default to 2.
+	HealthyThreshold   int
+	UnhealthyThreshold int
+	// DeregistrationDelaySeconds is how long requests in flight are given to finish when a task stops, up to 3600.
+	// Defaults to 30.
+	DeregistrationDelaySeconds int
+	// SlowStartSeconds ramps up the share of requests a new task gets over 30 to 900 seconds. Off by default.
+	SlowStartSeconds int
+	// StickinessSeconds keeps a client on the same task for 1 to 604800 seconds with a load balancer cookie, which
+	// keeps wp-admin sessions together. Off by default.
+	StickinessSeconds int
+}
+
+// SiteTargetGroup is the target group settings of the site, with the defaults filled in.
+func (config *TemplateConfig) SiteTargetGroup(site *Site) *TargetGroupConfig {
+	targetGroup := defaultTargetGroup
+	if siteTargetGroup := config.SiteConfig(site).TargetGroup; siteTargetGroup != nil {
+		targetGroup.override(siteTargetGroup)
+	}
+
+	return &targetGroup
+}
+
+func (c *TargetGroupConfig) override(other *TargetGroupConfig) {
+	if other.HealthCheckPath != "" {
+		c.HealthCheckPath = other.HealthCheckPath
+	}
+	if other.HealthCheckMatcher != "" {
+		c.HealthCheckMatcher = other.HealthCheckMatcher
+	}
+	for _, setting := range []struct {
+		value    *int
+		override int
+	}{
+		{&c.HealthCheckIntervalSeconds, other.HealthCheckIntervalSeconds},
+		{&c.HealthCheckTimeoutSeconds, other.HealthCheckTimeoutSeconds},
+		{&c.HealthyThreshold, other.HealthyThreshold},
+		{&c.UnhealthyThreshold, other.UnhealthyThreshold},
+		{&c.DeregistrationDelaySeconds, other.DeregistrationDelaySeconds},
+		{&c.SlowStartSeconds, other.SlowStartSeconds},
+		{&c.StickinessSeconds, other.StickinessSeconds},
+	} {
+		if setting.override != 0 {
+			*setting.value = setting.override
+		}
+	}
+}
+
+func (c *TargetGroupConfig) validate(siteName string) error {
+	targetGroup := defaultTargetGroup
+	targetGroup.override(c)
+
+	if !strings.HasPrefix(targetGroup.HealthCheckPath, "/") {
+		return targetGroup.invalidSetting(siteName, "HealthCheckPath", targetGroup.HealthCheckPath, "must start with '/'")
+	}
+	if !healthCheckMatcherPattern.MatchString(targetGroup.HealthCheckMatcher) {
+		return targetGroup.invalidSetting(
+			siteName, "HealthCheckMatcher", targetGroup.HealthCheckMatcher,
+			"must be HTTP codes or ranges of codes e.g. '200,301,302' or '200-399'",
+		)
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value int
+		min   int
+		max   int
+	}{
+		{"HealthCheckIntervalSeconds", targetGroup.HealthCheckIntervalSeconds, 5, 300},
+		{"HealthCheckTimeoutSeconds", targetGroup.HealthCheckTimeoutSeconds, 2, 120},
+		{"HealthyThreshold", targetGroup.HealthyThreshold, 2, 10},
+		{"UnhealthyThreshold", targetGroup.UnhealthyThreshold, 2, 10},
+		{"DeregistrationDelaySeconds", targetGroup.DeregistrationDelaySeconds, 1, 3600},
+		{"SlowStartSeconds", targetGroup.SlowStartSeconds, 30, 900},
+		{"StickinessSeconds", targetGroup.StickinessSeconds, 1, 604800},
+	} {
+		// slow start and stickiness are off unless they are set
+		if setting.value == 0 && (setting.name == "SlowStartSeconds" || setting.name == "StickinessSeconds") {
+			continue
+		}
+		if setting.value < setting.min || setting.value > setting.max {
+			return targetGroup.invalidSetting(
+				siteName, setting.name, fmt.Sprint(setting.value),
+				fmt.Sprintf("must be between %d and %d", setting.min, setting.max),
+			)
+		}
+	}
+
+	if targetGroup.HealthCheckTimeoutSeconds >= targetGroup.HealthCheckIntervalSeconds {
+		return targetGroup.invalidSetting(
+			siteName, "HealthCheckTimeoutSeconds", fmt.Sprint(targetGroup.HealthCheckTimeoutSeconds),
+			fmt.Sprintf("must be less than the interval of %d seconds", targetGroup.HealthCheckIntervalSeconds),
+		)
+	}
+
+	return nil
+}
+
+func (c *TargetGroupConfig) invalidSetting(siteName string, setting string, value string, reason string) error {
+	return &InvalidSiteSettingError{
+		Site: siteName, Setting: fmt.Sprintf("TargetGroup.%s", setting), Value: value, Reason: reason,
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestTargetGroupConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		targetGroup TargetGroupConfig
+		setting     string
+	}{
+		{"defaults", TargetGroupConfig{}, ""},
+		{
+			"tuned",
+			TargetGroupConfig{
+				HealthCheckPath: "/wp-includes/images/blank.gif", HealthCheckMatcher: "200-299,301",
+				HealthCheckIntervalSeconds: 30, HealthCheckTimeoutSeconds: 29, SlowStartSeconds: 30,
+				StickinessSeconds: 604800,
+			},
+			"",
+		},
+		{"relative path", TargetGroupConfig{HealthCheckPath: "health"}, "TargetGroup.HealthCheckPath"},
+		{"matcher class", TargetGroupConfig{HealthCheckMatcher: "2xx"}, "TargetGroup.HealthCheckMatcher"},
+		{"matcher with spaces", TargetGroupConfig{HealthCheckMatcher: "200, 301"}, "TargetGroup.HealthCheckMatcher"},
+		{
+			"interval too short",
+			TargetGroupConfig{HealthCheckIntervalSeconds: 4},
+			"TargetGroup.HealthCheckIntervalSeconds",
+		},
+		{
+			"timeout as long as the default interval",
+			TargetGroupConfig{HealthCheckTimeoutSeconds: 10},
+			"TargetGroup.HealthCheckTimeoutSeconds",
+		},
+		{"healthy threshold too high", TargetGroupConfig{HealthyThreshold: 11}, "TargetGroup.HealthyThreshold"},
+		{"unhealthy threshold too low", TargetGroupConfig{UnhealthyThreshold: 1}, "TargetGroup.UnhealthyThreshold"},
+		{
+			"deregistration delay too long",
+			TargetGroupConfig{DeregistrationDelaySeconds: 3601},
+			"TargetGroup.DeregistrationDelaySeconds",
+		},
+		{"slow start too short", TargetGroupConfig{SlowStartSeconds: 29}, "TargetGroup.SlowStartSeconds"},
+		{"stickiness too long", TargetGroupConfig{StickinessSeconds: 604801}, "TargetGroup.StickinessSeconds"},
+	} {
+		err := test.targetGroup.validate("blog")
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		siteError, ok := err.(*InvalidSiteSettingError)
+		if !ok || siteError.Site != "blog" || siteError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
+
+func TestSiteTargetGroup(t *testing.T) {
+	config := &TemplateConfig{Service: &ServiceConfig{Sites: map[string]*SiteConfig{
+		"blog": {TargetGroup: &TargetGroupConfig{HealthCheckPath: "/health", StickinessSeconds: 3600}},
+	}}}
+
+	expected := defaultTargetGroup
+	expected.HealthCheckPath = "/health"
+	expected.StickinessSeconds = 3600
+
+	if targetGroup := config.SiteTargetGroup(&Site{Name: "blog"}); *targetGroup != expected {
+		t.Errorf("expected %+v, got %+v", expected, *targetGroup)
+	}
+	if targetGroup := config.SiteTargetGroup(&Site{Name: "shop"}); *targetGroup != defaultTargetGroup {
+		t.Errorf("expected the defaults, got %+v", *targetGroup)
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"net"
+)
+
+var WafCountMode = "count"
+var WafBlockMode = "block"
+
+var defaultWafLoginRateLimit = 100
+var minWafRateLimit = 100
+
+// WafManagedRuleGroups are the AWS managed rule groups in the web ACL: the core rule set, known bad inputs, the
+// WordPress rules and the Amazon IP reputation list.
+var WafManagedRuleGroups = []string{
+	"AWSManagedRulesCommonRuleSet",
+	"AWSManagedRulesKnownBadInputsRuleSet",
+	"AWSManagedRulesWordPressRuleSet",
+	"AWSManagedRulesAmazonIpReputationList",
+}
+
+// WafLoginPaths are rate limited per client IP, as they are the targets of brute force attacks on WordPress.
+var WafLoginPaths = []string{"/wp-login.php", "/xmlrpc.php"}
+
+// WafConfig puts a web ACL in front of the load balancer with the AWS managed rule groups, a rate limit on the login
+// paths and a blocklist of IP ranges.
+type WafConfig struct {
+	// Mode is either 'count', which only counts the requests the rate limit and the managed rule groups match, or
+	// 'block'. Defaults to counting, so that the rules can be checked against real traffic before they block it.
+	Mode string
+	// LoginRateLimit is the number of requests to the login paths a client IP may make in 5 minutes. Defaults to 100.
+	LoginRateLimit int
+	// BlockedCidrs are the IPv4 and IPv6 ranges whose requests are blocked outright, in either mode. Each must be given
+	// by its network address e.g. '203.0.113.0/24', as WAF rejects ranges with host bits set.
+	BlockedCidrs []string
+}
+
+// IsBlocking is whether the rules block the requests they match rather than only counting them.
+func (c *WafConfig) IsBlocking() bool {
+	return c.Mode == WafBlockMode
+}
+
+func (c *WafConfig) RateLimit() int {
+	if c.LoginRateLimit == 0 {
+		return defaultWafLoginRateLimit
+	}
+	return c.LoginRateLimit
+}
+
+// BlockedIpv4Cidrs and BlockedIpv6Cidrs split the blocklist by IP version, which WAF keeps in separate IP sets.
+func (c *WafConfig) BlockedIpv4Cidrs() []string {
+	return c.blockedCidrs(true)
+}
+
+func (c *WafConfig) BlockedIpv6Cidrs() []string {
+	return c.blockedCidrs(false)
+}
+
+func (c *WafConfig) blockedCidrs(ipv4 bool) []string {
+	var cidrs []string
+	for _, cidr := range c.BlockedCidrs {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil && (ipNet.IP.To4() != nil) == ipv4 {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+	return cidrs
+}
+
+func (c *WafConfig) validate(stageName string, stageConfig *StageConfig) error {
+	switch c.Mode {
+	case "", WafCountMode, WafBlockMode:
+	default:
+		return stageConfig.invalidSetting(
+			stageName, "Waf.Mode", c.Mode, fmt.Sprintf("choose from %s", []string{WafCountMode, WafBlockMode}),
+		)
+	}
+
+	if c.RateLimit() < minWafRateLimit {
+		return stageConfig.invalidSetting(
+			stageName, "Waf.LoginRateLimit", fmt.Sprint(c.LoginRateLimit),
+			fmt.Sprintf("must be at least %d", minWafRateLimit),
+		)
+	}
+
+	for _, cidr := range c.BlockedCidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return stageConfig.invalidSetting(stageName, "Waf.BlockedCidrs", cidr, "must be a CIDR block")
+		}
+		if ipNet.String() != cidr {
+			return stageConfig.invalidSetting(
+				stageName, "Waf.BlockedCidrs", cidr, fmt.Sprintf("must be given as its network '%s'", ipNet),
+			)
+		}
+	}
+
+	return nil
+}
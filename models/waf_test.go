@@ -0,0 +1,47 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWafConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		waf     WafConfig
+		setting string
+	}{
+		{"defaults", WafConfig{}, ""},
+		{"blocking", WafConfig{Mode: WafBlockMode, LoginRateLimit: 500}, ""},
+		{"blocklist", WafConfig{BlockedCidrs: []string{"203.0.113.0/24", "2001:db8::/32"}}, ""},
+		{"unknown mode", WafConfig{Mode: "deny"}, "Waf.Mode"},
+		{"rate limit below the minimum", WafConfig{LoginRateLimit: 99}, "Waf.LoginRateLimit"},
+		{"address without a prefix", WafConfig{BlockedCidrs: []string{"203.0.113.7"}}, "Waf.BlockedCidrs"},
+		{"host bits set", WafConfig{BlockedCidrs: []string{"203.0.113.1/24"}}, "Waf.BlockedCidrs"},
+		{"IPv6 host bits set", WafConfig{BlockedCidrs: []string{"2001:db8::1/32"}}, "Waf.BlockedCidrs"},
+		{"IPv6 range not shortened", WafConfig{BlockedCidrs: []string{"2001:0db8::/32"}}, "Waf.BlockedCidrs"},
+	} {
+		err := test.waf.validate("Gamma", &StageConfig{Waf: &test.waf})
+		if test.setting == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		if settingError, ok := err.(*InvalidStageSettingError); !ok || settingError.Setting != test.setting {
+			t.Errorf("%s: expected an invalid %s, got %v", test.name, test.setting, err)
+		}
+	}
+}
+
+func TestWafBlockedCidrs(t *testing.T) {
+	waf := &WafConfig{BlockedCidrs: []string{"203.0.113.0/24", "2001:db8::/32", "198.51.100.0/24"}}
+
+	if ipv4 := waf.BlockedIpv4Cidrs(); !reflect.DeepEqual(ipv4, []string{"203.0.113.0/24", "198.51.100.0/24"}) {
+		t.Errorf("unexpected IPv4 ranges %s", ipv4)
+	}
+	if ipv6 := waf.BlockedIpv6Cidrs(); !reflect.DeepEqual(ipv6, []string{"2001:db8::/32"}) {
+		t.Errorf("unexpected IPv6 ranges %s", ipv6)
+	}
+}
@@ -0,0 +1,79 @@
+package template_rsrcs
+
+import (
+	"fmt"
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/constants"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+func (s *ServiceResources) hasAccessLogs() bool {
+	return s.Config.StageConfig().AccessLogs != nil
+}
+
+func (s *ServiceResources) accessLogBucketLogicalName() string {
+	return s.Config.CfName("AlbAccessLogBucket")
+}
+
+func (s *ServiceResources) accessLogBucketPolicyLogicalName() string {
+	return s.Config.CfName("AlbAccessLogBucketPolicy")
+}
+
+// addAccessLogBucket adds the bucket the load balancer delivers its access logs to. Only the region's load balancing
+// account may write to it, and only under the stage's prefix. The bucket is retained when the stack is deleted, as a
+// bucket that still holds logs cannot be deleted.
+func (s *ServiceResources) addAccessLogBucket() {
+	s.Template.Resources[s.accessLogBucketLogicalName()] = &Resource{
+		DeletionPolicy: "Retain",
+		Properties: cf_rsrcs.PrivateLogBucket(
+			int64(s.Config.StageConfig().AccessLogs.Retention()), s.Config.ResourceTags(),
+		),
+	}
+
+	s.Template.AddResource(
+		s.accessLogBucketPolicyLogicalName(),
+		&S3BucketPolicy{
+			Bucket: Ref(s.accessLogBucketLogicalName()).String(),
+			PolicyDocument: map[string]interface{}{
+				"Version": "2012-10-17",
+				"Statement": []interface{}{
+					map[string]interface{}{
+						"Effect": "Allow",
+						"Principal": map[string]interface{}{
+							"AWS": Sub(String(fmt.Sprintf(
+								"arn:${AWS::Partition}:iam::%s:root", s.Config.Region.ElbAccountId(),
+							))),
+						},
+						"Action": "s3:PutObject",
+						"Resource": Sub(String(fmt.Sprintf(
+							"arn:${AWS::Partition}:s3:::${%s}/%s/AWSLogs/${AWS::AccountId}/*",
+							s.accessLogBucketLogicalName(), s.Config.AccessLogPrefix(),
+						))),
+					},
+				},
+			},
+		},
+	)
+
+	s.Template.Outputs[s.Config.CfName(AccessLogBucketOutputName)] = &Output{
+		Description: "Bucket of the Elastic Load Balancer access logs",
+		Value:       Ref(s.accessLogBucketLogicalName()),
+	}
+}
+
+func (s *ServiceResources) accessLogAttributes() ElasticLoadBalancingLoadBalancerLoadBalancerAttributesList {
+	return ElasticLoadBalancingLoadBalancerLoadBalancerAttributesList{
+		ElasticLoadBalancingLoadBalancerLoadBalancerAttributes{
+			Key:   String("access_logs.s3.enabled"),
+			Value: String("true"),
+		},
+		ElasticLoadBalancingLoadBalancerLoadBalancerAttributes{
+			Key:   String("access_logs.s3.bucket"),
+			Value: Ref(s.accessLogBucketLogicalName()).String(),
+		},
+		ElasticLoadBalancingLoadBalancerLoadBalancerAttributes{
+			Key:   String("access_logs.s3.prefix"),
+			Value: String(s.Config.AccessLogPrefix()),
+		},
+	}
+}
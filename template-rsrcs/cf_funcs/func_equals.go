@@ -0,0 +1,14 @@
+package cf_funcs
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+)
+
+// Equals represents the Fn::Equals function comparing two values, which conditions are made of.
+func Equals(value Stringable, other Stringable) EqualsFunc {
+	return EqualsFunc{Values: []StringExpr{*value.String(), *other.String()}}
+}
+
+type EqualsFunc struct {
+	Values []StringExpr `json:"Fn::Equals"`
+}
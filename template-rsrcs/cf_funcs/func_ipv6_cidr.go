@@ -0,0 +1,44 @@
+package cf_funcs
+
+import (
+	"encoding/json"
+	"strconv"
+	. "github.com/crewjam/go-cloudformation"
+)
+
+var ipv6SubnetBits = "64"
+
+// Ipv6SubnetCidr is the index'th /64 block out of the Amazon provided IPv6 block of the VPC, which is only known once
+// the VPC has been created. It expands to:
+//   Fn::Select [index, Fn::Cidr [Fn::Select [0, Fn::GetAtt [vpc, Ipv6CidrBlocks]], count, 64]]
+func Ipv6SubnetCidr(vpcLogicalName string, index int, count int) *StringExpr {
+	return Ipv6SubnetCidrFunc{VpcLogicalName: vpcLogicalName, Index: index, Count: count}.String()
+}
+
+type Ipv6SubnetCidrFunc struct {
+	VpcLogicalName string
+	Index          int
+	Count          int
+}
+
+func (f Ipv6SubnetCidrFunc) MarshalJSON() ([]byte, error) {
+	vpcIpv6Block := map[string]interface{}{
+		"Fn::Select": []interface{}{"0", map[string]interface{}{
+			"Fn::GetAtt": []string{f.VpcLogicalName, "Ipv6CidrBlocks"},
+		}},
+	}
+	subnetBlocks := map[string]interface{}{
+		"Fn::Cidr": []interface{}{vpcIpv6Block, strconv.Itoa(f.Count), ipv6SubnetBits},
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"Fn::Select": []interface{}{strconv.Itoa(f.Index), subnetBlocks},
+	})
+}
+
+func (f Ipv6SubnetCidrFunc) String() *StringExpr {
+	return &StringExpr{Func: f}
+}
+
+var _ Stringable = Ipv6SubnetCidrFunc{} // Ipv6SubnetCidrFunc must implement Stringable
+var _ StringFunc = Ipv6SubnetCidrFunc{} // Ipv6SubnetCidrFunc must implement StringFunc
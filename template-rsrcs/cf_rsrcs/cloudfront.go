@@ -0,0 +1,76 @@
+package cf_rsrcs
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+)
+
+// CloudFrontHostedZoneId is the hosted zone of every CloudFront distribution, which alias records point at.
+var CloudFrontHostedZoneId = "Z2FDTNDATAQYW2"
+
+// Distribution is a CloudFront distribution. Only the settings used to put it in front of a custom origin are modelled.
+type Distribution struct {
+	DistributionConfig *DistributionConfig `json:"DistributionConfig,omitempty"`
+	Tags               []ResourceTag       `json:"Tags,omitempty"`
+}
+
+func (r Distribution) CfnResourceType() string {
+	return "AWS::CloudFront::Distribution"
+}
+
+type DistributionConfig struct {
+	Aliases              *StringListExpr      `json:"Aliases,omitempty"`
+	CacheBehaviors       []CacheBehavior      `json:"CacheBehaviors,omitempty"`
+	Comment              *StringExpr          `json:"Comment,omitempty"`
+	DefaultCacheBehavior *CacheBehavior       `json:"DefaultCacheBehavior,omitempty"`
+	Enabled              *BoolExpr            `json:"Enabled,omitempty"`
+	HttpVersion          *StringExpr          `json:"HttpVersion,omitempty"`
+	IPV6Enabled          *BoolExpr            `json:"IPV6Enabled,omitempty"`
+	Origins              []DistributionOrigin `json:"Origins,omitempty"`
+	PriceClass           *StringExpr          `json:"PriceClass,omitempty"`
+	ViewerCertificate    *ViewerCertificate   `json:"ViewerCertificate,omitempty"`
+}
+
+// CacheBehavior is the default cache behavior when it has no path pattern.
+type CacheBehavior struct {
+	AllowedMethods       *StringListExpr  `json:"AllowedMethods,omitempty"`
+	CachedMethods        *StringListExpr  `json:"CachedMethods,omitempty"`
+	Compress             *BoolExpr        `json:"Compress,omitempty"`
+	DefaultTTL           *IntegerExpr     `json:"DefaultTTL,omitempty"`
+	ForwardedValues      *ForwardedValues `json:"ForwardedValues,omitempty"`
+	MaxTTL               *IntegerExpr     `json:"MaxTTL,omitempty"`
+	MinTTL               *IntegerExpr     `json:"MinTTL,omitempty"`
+	PathPattern          *StringExpr      `json:"PathPattern,omitempty"`
+	TargetOriginId       *StringExpr      `json:"TargetOriginId,omitempty"`
+	ViewerProtocolPolicy *StringExpr      `json:"ViewerProtocolPolicy,omitempty"`
+}
+
+// ForwardedValues are the parts of the request passed on to the origin, which are also part of the cache key.
+type ForwardedValues struct {
+	Cookies     *ForwardedCookies `json:"Cookies,omitempty"`
+	Headers     *StringListExpr   `json:"Headers,omitempty"`
+	QueryString *BoolExpr         `json:"QueryString"`
+}
+
+type ForwardedCookies struct {
+	Forward          *StringExpr     `json:"Forward,omitempty"`
+	WhitelistedNames *StringListExpr `json:"WhitelistedNames,omitempty"`
+}
+
+type DistributionOrigin struct {
+	CustomOriginConfig *CustomOriginConfig `json:"CustomOriginConfig,omitempty"`
+	DomainName         *StringExpr         `json:"DomainName,omitempty"`
+	Id                 *StringExpr         `json:"Id,omitempty"`
+}
+
+type CustomOriginConfig struct {
+	HTTPPort             *IntegerExpr    `json:"HTTPPort,omitempty"`
+	HTTPSPort            *IntegerExpr    `json:"HTTPSPort,omitempty"`
+	OriginProtocolPolicy *StringExpr     `json:"OriginProtocolPolicy,omitempty"`
+	OriginSSLProtocols   *StringListExpr `json:"OriginSSLProtocols,omitempty"`
+}
+
+type ViewerCertificate struct {
+	AcmCertificateArn      *StringExpr `json:"AcmCertificateArn,omitempty"`
+	MinimumProtocolVersion *StringExpr `json:"MinimumProtocolVersion,omitempty"`
+	SslSupportMethod       *StringExpr `json:"SslSupportMethod,omitempty"`
+}
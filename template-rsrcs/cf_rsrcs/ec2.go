@@ -0,0 +1,92 @@
+package cf_rsrcs
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+)
+
+type Subnet struct {
+	*EC2Subnet
+	AssignIpv6AddressOnCreation *BoolExpr   `json:"AssignIpv6AddressOnCreation,omitempty"`
+	Ipv6CidrBlock               *StringExpr `json:"Ipv6CidrBlock,omitempty"`
+}
+
+type Route struct {
+	*EC2Route
+	DestinationIpv6CidrBlock    *StringExpr `json:"DestinationIpv6CidrBlock,omitempty"`
+	EgressOnlyInternetGatewayId *StringExpr `json:"EgressOnlyInternetGatewayId,omitempty"`
+}
+
+type SecurityGroupIngress struct {
+	*EC2SecurityGroupIngress
+	CidrIpv6 *StringExpr `json:"CidrIpv6,omitempty"`
+}
+
+type SecurityGroupEgress struct {
+	*EC2SecurityGroupEgress
+	CidrIpv6 *StringExpr `json:"CidrIpv6,omitempty"`
+}
+
+// VPCCidrBlock associates an additional CIDR block, such as an Amazon provided IPv6 block, with a VPC.
+type VPCCidrBlock struct {
+	AmazonProvidedIpv6CidrBlock *BoolExpr   `json:"AmazonProvidedIpv6CidrBlock,omitempty"`
+	CidrBlock                   *StringExpr `json:"CidrBlock,omitempty"`
+	VpcId                       *StringExpr `json:"VpcId,omitempty"`
+}
+
+func (r VPCCidrBlock) CfnResourceType() string {
+	return "AWS::EC2::VPCCidrBlock"
+}
+
+// EgressOnlyInternetGateway lets IPv6 traffic out of private subnets without letting connections in.
+type EgressOnlyInternetGateway struct {
+	VpcId *StringExpr `json:"VpcId,omitempty"`
+}
+
+func (r EgressOnlyInternetGateway) CfnResourceType() string {
+	return "AWS::EC2::EgressOnlyInternetGateway"
+}
+
+// VPCEndpoint is a private connection from the VPC to an AWS service. Gateway endpoints are added to route tables,
+// interface endpoints are network interfaces in subnets.
+type VPCEndpoint struct {
+	PolicyDocument    interface{}     `json:"PolicyDocument,omitempty"`
+	PrivateDnsEnabled *BoolExpr       `json:"PrivateDnsEnabled,omitempty"`
+	RouteTableIds     *StringListExpr `json:"RouteTableIds,omitempty"`
+	SecurityGroupIds  *StringListExpr `json:"SecurityGroupIds,omitempty"`
+	ServiceName       *StringExpr     `json:"ServiceName,omitempty"`
+	SubnetIds         *StringListExpr `json:"SubnetIds,omitempty"`
+	VpcEndpointType   *StringExpr     `json:"VpcEndpointType,omitempty"`
+	VpcId             *StringExpr     `json:"VpcId,omitempty"`
+}
+
+func (r VPCEndpoint) CfnResourceType() string {
+	return "AWS::EC2::VPCEndpoint"
+}
+
+// FlowLog adds the destination properties that let flow logs be delivered to S3 rather than CloudWatch Logs.
+type FlowLog struct {
+	*EC2FlowLog
+	LogDestination     *StringExpr `json:"LogDestination,omitempty"`
+	LogDestinationType *StringExpr `json:"LogDestinationType,omitempty"`
+}
+
+// NetworkAclEntry is a rule in a network ACL, which may match an IPv6 range as well as an IPv4 one.
+type NetworkAclEntry struct {
+	CidrBlock     *StringExpr   `json:"CidrBlock,omitempty"`
+	Egress        *BoolExpr     `json:"Egress,omitempty"`
+	Ipv6CidrBlock *StringExpr   `json:"Ipv6CidrBlock,omitempty"`
+	NetworkAclId  *StringExpr   `json:"NetworkAclId,omitempty"`
+	PortRange     *AclPortRange `json:"PortRange,omitempty"`
+	Protocol      *IntegerExpr  `json:"Protocol,omitempty"`
+	RuleAction    *StringExpr   `json:"RuleAction,omitempty"`
+	RuleNumber    *IntegerExpr  `json:"RuleNumber,omitempty"`
+}
+
+func (r NetworkAclEntry) CfnResourceType() string {
+	return "AWS::EC2::NetworkAclEntry"
+}
+
+type AclPortRange struct {
+	From *IntegerExpr `json:"From,omitempty"`
+	To   *IntegerExpr `json:"To,omitempty"`
+}
@@ -0,0 +1,105 @@
+package cf_rsrcs
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+)
+
+type LoadBalancer struct {
+	*ElasticLoadBalancingV2LoadBalancer
+	IpAddressType *StringExpr `json:"IpAddressType,omitempty"`
+}
+
+// Listener replaces the default actions of the library listener with ones that can redirect.
+type Listener struct {
+	*ElasticLoadBalancingV2Listener
+	DefaultActions []ListenerAction `json:"DefaultActions,omitempty"`
+}
+
+// ListenerAction is a listener or listener rule action, which forwards to a target group, redirects the request,
+// answers it with a fixed response or signs the visitor in. Actions are taken in order when there are several.
+type ListenerAction struct {
+	AuthenticateCognitoConfig *AuthenticateCognitoConfig `json:"AuthenticateCognitoConfig,omitempty"`
+	AuthenticateOidcConfig    *AuthenticateOidcConfig    `json:"AuthenticateOidcConfig,omitempty"`
+	FixedResponseConfig       *FixedResponseConfig       `json:"FixedResponseConfig,omitempty"`
+	Order                     *IntegerExpr               `json:"Order,omitempty"`
+	RedirectConfig            *RedirectConfig            `json:"RedirectConfig,omitempty"`
+	TargetGroupArn            *StringExpr                `json:"TargetGroupArn,omitempty"`
+	Type                      *StringExpr                `json:"Type,omitempty"`
+}
+
+type AuthenticateCognitoConfig struct {
+	UserPoolArn      *StringExpr `json:"UserPoolArn,omitempty"`
+	UserPoolClientId *StringExpr `json:"UserPoolClientId,omitempty"`
+	UserPoolDomain   *StringExpr `json:"UserPoolDomain,omitempty"`
+}
+
+type AuthenticateOidcConfig struct {
+	AuthorizationEndpoint *StringExpr `json:"AuthorizationEndpoint,omitempty"`
+	ClientId              *StringExpr `json:"ClientId,omitempty"`
+	ClientSecret          *StringExpr `json:"ClientSecret,omitempty"`
+	Issuer                *StringExpr `json:"Issuer,omitempty"`
+	TokenEndpoint         *StringExpr `json:"TokenEndpoint,omitempty"`
+	UserInfoEndpoint      *StringExpr `json:"UserInfoEndpoint,omitempty"`
+}
+
+type FixedResponseConfig struct {
+	ContentType *StringExpr `json:"ContentType,omitempty"`
+	MessageBody *StringExpr `json:"MessageBody,omitempty"`
+	StatusCode  *StringExpr `json:"StatusCode,omitempty"`
+}
+
+// RedirectConfig builds the redirect URL out of the original one. Any part that is not given is kept, and the
+// '#{host}', '#{path}' and '#{query}' placeholders refer to the original parts.
+type RedirectConfig struct {
+	Host       *StringExpr `json:"Host,omitempty"`
+	Path       *StringExpr `json:"Path,omitempty"`
+	Port       *StringExpr `json:"Port,omitempty"`
+	Protocol   *StringExpr `json:"Protocol,omitempty"`
+	Query      *StringExpr `json:"Query,omitempty"`
+	StatusCode *StringExpr `json:"StatusCode,omitempty"`
+}
+
+// ListenerRule replaces the actions of the library listener rule with ones that can redirect, answer themselves or sign
+// the visitor in, and its conditions with ones that can match the source IP.
+type ListenerRule struct {
+	*ElasticLoadBalancingV2ListenerRule
+	Actions    []ListenerAction        `json:"Actions,omitempty"`
+	Conditions []ListenerRuleCondition `json:"Conditions,omitempty"`
+}
+
+// ListenerRuleCondition matches the host header on the hosts of its host header config, or the source IP on the ranges
+// of its source IP config. The values of the condition itself only take a single value e.g. the path pattern.
+type ListenerRuleCondition struct {
+	Field            *StringExpr       `json:"Field,omitempty"`
+	HostHeaderConfig *HostHeaderConfig `json:"HostHeaderConfig,omitempty"`
+	SourceIpConfig   *SourceIpConfig   `json:"SourceIpConfig,omitempty"`
+	Values           *StringListExpr   `json:"Values,omitempty"`
+}
+
+type HostHeaderConfig struct {
+	Values *StringListExpr `json:"Values,omitempty"`
+}
+
+type SourceIpConfig struct {
+	Values *StringListExpr `json:"Values,omitempty"`
+}
+
+// TargetGroup adds the target type to the library target group, which is 'ip' for the tasks of awsvpc services.
+type TargetGroup struct {
+	*ElasticLoadBalancingV2TargetGroup
+	TargetType *StringExpr `json:"TargetType,omitempty"`
+}
+
+// ListenerCertificate adds certificates to an HTTPS listener, which picks the certificate for each request by SNI.
+type ListenerCertificate struct {
+	Certificates []ListenerCertificateArn `json:"Certificates,omitempty"`
+	ListenerArn  *StringExpr              `json:"ListenerArn,omitempty"`
+}
+
+func (r ListenerCertificate) CfnResourceType() string {
+	return "AWS::ElasticLoadBalancingV2::ListenerCertificate"
+}
+
+type ListenerCertificateArn struct {
+	CertificateArn *StringExpr `json:"CertificateArn,omitempty"`
+}
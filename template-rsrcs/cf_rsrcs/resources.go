@@ -0,0 +1,118 @@
+// Package cf_rsrcs holds CloudFormation resource properties that are missing from the vendored go-cloudformation
+// library. The types wrap the library resource and add the missing properties alongside it, so they are serialized into
+// the same JSON object.
+package cf_rsrcs
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+)
+
+// AutoScalingTag is an auto scaling group tag, which can be propagated to the instances the group launches.
+type AutoScalingTag struct {
+	Key               *StringExpr `json:"Key,omitempty"`
+	Value             *StringExpr `json:"Value,omitempty"`
+	PropagateAtLaunch *BoolExpr   `json:"PropagateAtLaunch,omitempty"`
+}
+
+// AutoScalingTags converts resource tags into auto scaling group tags that are propagated to launched instances.
+func AutoScalingTags(tags []ResourceTag) []AutoScalingTag {
+	var asgTags []AutoScalingTag
+	for _, tag := range tags {
+		asgTags = append(asgTags, AutoScalingTag{Key: tag.Key, Value: tag.Value, PropagateAtLaunch: Bool(true)})
+	}
+
+	return asgTags
+}
+
+type AutoScalingGroup struct {
+	*AutoScalingAutoScalingGroup
+	Tags []AutoScalingTag `json:"Tags,omitempty"`
+}
+
+type FileSystem struct {
+	*EFSFileSystem
+	FileSystemTags []ResourceTag `json:"FileSystemTags,omitempty"`
+}
+
+type Service struct {
+	*ECSService
+	LaunchType           *StringExpr           `json:"LaunchType,omitempty"`
+	NetworkConfiguration *NetworkConfiguration `json:"NetworkConfiguration,omitempty"`
+	PlatformVersion      *StringExpr           `json:"PlatformVersion,omitempty"`
+	PropagateTags        *StringExpr           `json:"PropagateTags,omitempty"`
+	Tags                 []ResourceTag         `json:"Tags,omitempty"`
+}
+
+// NetworkConfiguration gives each task of an awsvpc service a network interface of its own in one of the subnets.
+type NetworkConfiguration struct {
+	AwsvpcConfiguration *AwsvpcConfiguration `json:"AwsvpcConfiguration,omitempty"`
+}
+
+type AwsvpcConfiguration struct {
+	AssignPublicIp *StringExpr     `json:"AssignPublicIp,omitempty"`
+	SecurityGroups *StringListExpr `json:"SecurityGroups,omitempty"`
+	Subnets        *StringListExpr `json:"Subnets,omitempty"`
+}
+
+// TaskDefinition adds the task size, the execution role, the network mode and the launch types to the library task
+// definition, and replaces its volumes with ones that can be EFS file systems.
+type TaskDefinition struct {
+	*ECSTaskDefinition
+	Cpu                     *StringExpr     `json:"Cpu,omitempty"`
+	ExecutionRoleArn        *StringExpr     `json:"ExecutionRoleArn,omitempty"`
+	Memory                  *StringExpr     `json:"Memory,omitempty"`
+	NetworkMode             *StringExpr     `json:"NetworkMode,omitempty"`
+	RequiresCompatibilities *StringListExpr `json:"RequiresCompatibilities,omitempty"`
+	Volumes                 []TaskVolume    `json:"Volumes,omitempty"`
+}
+
+// TaskVolume is either a path on the host or an EFS file system.
+type TaskVolume struct {
+	EFSVolumeConfiguration *EFSVolumeConfiguration `json:"EFSVolumeConfiguration,omitempty"`
+	Host                   *TaskVolumeHost         `json:"Host,omitempty"`
+	Name                   *StringExpr             `json:"Name,omitempty"`
+}
+
+type TaskVolumeHost struct {
+	SourcePath *StringExpr `json:"SourcePath,omitempty"`
+}
+
+// EFSVolumeConfiguration mounts the file system through an access point, which needs encryption in transit.
+type EFSVolumeConfiguration struct {
+	AuthorizationConfig *EFSAuthorizationConfig `json:"AuthorizationConfig,omitempty"`
+	FilesystemId        *StringExpr             `json:"FilesystemId,omitempty"`
+	TransitEncryption   *StringExpr             `json:"TransitEncryption,omitempty"`
+}
+
+type EFSAuthorizationConfig struct {
+	AccessPointId *StringExpr `json:"AccessPointId,omitempty"`
+	IAM           *StringExpr `json:"IAM,omitempty"`
+}
+
+// AccessPoint exposes a directory of an EFS file system as its root, creating the directory if it does not exist.
+type AccessPoint struct {
+	AccessPointTags []ResourceTag  `json:"AccessPointTags,omitempty"`
+	FileSystemId    *StringExpr    `json:"FileSystemId,omitempty"`
+	RootDirectory   *RootDirectory `json:"RootDirectory,omitempty"`
+}
+
+func (r AccessPoint) CfnResourceType() string {
+	return "AWS::EFS::AccessPoint"
+}
+
+type RootDirectory struct {
+	CreationInfo *CreationInfo `json:"CreationInfo,omitempty"`
+	Path         *StringExpr   `json:"Path,omitempty"`
+}
+
+// CreationInfo is the owner and permissions the root directory is created with.
+type CreationInfo struct {
+	OwnerGid    *StringExpr `json:"OwnerGid,omitempty"`
+	OwnerUid    *StringExpr `json:"OwnerUid,omitempty"`
+	Permissions *StringExpr `json:"Permissions,omitempty"`
+}
+
+type LogGroup struct {
+	*LogsLogGroup
+	Tags []ResourceTag `json:"Tags,omitempty"`
+}
@@ -0,0 +1,69 @@
+package cf_rsrcs
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+)
+
+// Bucket is an S3 bucket with the encryption, public access block and lifecycle properties used for log buckets.
+type Bucket struct {
+	BucketEncryption               *BucketEncryption               `json:"BucketEncryption,omitempty"`
+	LifecycleConfiguration         *LifecycleConfiguration         `json:"LifecycleConfiguration,omitempty"`
+	PublicAccessBlockConfiguration *PublicAccessBlockConfiguration `json:"PublicAccessBlockConfiguration,omitempty"`
+	Tags                           []ResourceTag                   `json:"Tags,omitempty"`
+}
+
+func (r Bucket) CfnResourceType() string {
+	return "AWS::S3::Bucket"
+}
+
+type BucketEncryption struct {
+	ServerSideEncryptionConfiguration []ServerSideEncryptionRule `json:"ServerSideEncryptionConfiguration,omitempty"`
+}
+
+type ServerSideEncryptionRule struct {
+	ServerSideEncryptionByDefault *ServerSideEncryptionByDefault `json:"ServerSideEncryptionByDefault,omitempty"`
+}
+
+type ServerSideEncryptionByDefault struct {
+	SSEAlgorithm *StringExpr `json:"SSEAlgorithm,omitempty"`
+}
+
+type LifecycleConfiguration struct {
+	Rules []LifecycleRule `json:"Rules,omitempty"`
+}
+
+type LifecycleRule struct {
+	ExpirationInDays *IntegerExpr `json:"ExpirationInDays,omitempty"`
+	Id               *StringExpr  `json:"Id,omitempty"`
+	Status           *StringExpr  `json:"Status,omitempty"`
+}
+
+type PublicAccessBlockConfiguration struct {
+	BlockPublicAcls       *BoolExpr `json:"BlockPublicAcls,omitempty"`
+	BlockPublicPolicy     *BoolExpr `json:"BlockPublicPolicy,omitempty"`
+	IgnorePublicAcls      *BoolExpr `json:"IgnorePublicAcls,omitempty"`
+	RestrictPublicBuckets *BoolExpr `json:"RestrictPublicBuckets,omitempty"`
+}
+
+// PrivateLogBucket is an encrypted bucket that blocks all public access and expires objects after the retention period.
+func PrivateLogBucket(retentionDays int64, tags []ResourceTag) *Bucket {
+	return &Bucket{
+		BucketEncryption: &BucketEncryption{
+			ServerSideEncryptionConfiguration: []ServerSideEncryptionRule{
+				{ServerSideEncryptionByDefault: &ServerSideEncryptionByDefault{SSEAlgorithm: String("AES256")}},
+			},
+		},
+		LifecycleConfiguration: &LifecycleConfiguration{
+			Rules: []LifecycleRule{
+				{ExpirationInDays: Integer(retentionDays), Id: String("ExpireLogs"), Status: String("Enabled")},
+			},
+		},
+		PublicAccessBlockConfiguration: &PublicAccessBlockConfiguration{
+			BlockPublicAcls:       Bool(true),
+			BlockPublicPolicy:     Bool(true),
+			IgnorePublicAcls:      Bool(true),
+			RestrictPublicBuckets: Bool(true),
+		},
+		Tags: tags,
+	}
+}
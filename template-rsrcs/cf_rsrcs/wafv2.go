@@ -0,0 +1,119 @@
+package cf_rsrcs
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+)
+
+// WebACL is a WAFv2 web ACL. Only the statements used to protect the load balancer are modelled.
+type WebACL struct {
+	DefaultAction    *WafAction        `json:"DefaultAction,omitempty"`
+	Name             *StringExpr       `json:"Name,omitempty"`
+	Rules            []WafRule         `json:"Rules,omitempty"`
+	Scope            *StringExpr       `json:"Scope,omitempty"`
+	Tags             []ResourceTag     `json:"Tags,omitempty"`
+	VisibilityConfig *VisibilityConfig `json:"VisibilityConfig,omitempty"`
+}
+
+func (r WebACL) CfnResourceType() string {
+	return "AWS::WAFv2::WebACL"
+}
+
+// WebACLAssociation puts the web ACL in front of a load balancer.
+type WebACLAssociation struct {
+	ResourceArn *StringExpr `json:"ResourceArn,omitempty"`
+	WebACLArn   *StringExpr `json:"WebACLArn,omitempty"`
+}
+
+func (r WebACLAssociation) CfnResourceType() string {
+	return "AWS::WAFv2::WebACLAssociation"
+}
+
+// IPSet is a list of IP ranges of one IP version, which rules refer to by ARN.
+type IPSet struct {
+	Addresses        *StringListExpr `json:"Addresses,omitempty"`
+	IPAddressVersion *StringExpr     `json:"IPAddressVersion,omitempty"`
+	Name             *StringExpr     `json:"Name,omitempty"`
+	Scope            *StringExpr     `json:"Scope,omitempty"`
+	Tags             []ResourceTag   `json:"Tags,omitempty"`
+}
+
+func (r IPSet) CfnResourceType() string {
+	return "AWS::WAFv2::IPSet"
+}
+
+// WafRule is a rule of a web ACL. Rules with their own statements take an action, while rules referring to rule
+// groups take an override action instead.
+type WafRule struct {
+	Action           *WafAction        `json:"Action,omitempty"`
+	Name             *StringExpr       `json:"Name,omitempty"`
+	OverrideAction   *WafAction        `json:"OverrideAction,omitempty"`
+	Priority         *IntegerExpr      `json:"Priority,omitempty"`
+	Statement        *WafStatement     `json:"Statement,omitempty"`
+	VisibilityConfig *VisibilityConfig `json:"VisibilityConfig,omitempty"`
+}
+
+// WafAction holds exactly one of the actions. The override action of a rule group is either None, which keeps the
+// actions of the group's rules, or Count.
+type WafAction struct {
+	Allow *EmptyObject `json:"Allow,omitempty"`
+	Block *EmptyObject `json:"Block,omitempty"`
+	Count *EmptyObject `json:"Count,omitempty"`
+	None  *EmptyObject `json:"None,omitempty"`
+}
+
+// EmptyObject is serialized as an empty object, which is how WAF spells out actions and parts of the request that have
+// no settings.
+type EmptyObject struct{}
+
+// WafStatement holds exactly one of the statements.
+type WafStatement struct {
+	ByteMatchStatement        *ByteMatchStatement        `json:"ByteMatchStatement,omitempty"`
+	IPSetReferenceStatement   *IPSetReferenceStatement   `json:"IPSetReferenceStatement,omitempty"`
+	ManagedRuleGroupStatement *ManagedRuleGroupStatement `json:"ManagedRuleGroupStatement,omitempty"`
+	OrStatement               *OrStatement               `json:"OrStatement,omitempty"`
+	RateBasedStatement        *RateBasedStatement        `json:"RateBasedStatement,omitempty"`
+}
+
+type ByteMatchStatement struct {
+	FieldToMatch         *FieldToMatch        `json:"FieldToMatch,omitempty"`
+	PositionalConstraint *StringExpr          `json:"PositionalConstraint,omitempty"`
+	SearchString         *StringExpr          `json:"SearchString,omitempty"`
+	TextTransformations  []TextTransformation `json:"TextTransformations,omitempty"`
+}
+
+// FieldToMatch holds exactly one part of the request.
+type FieldToMatch struct {
+	UriPath *EmptyObject `json:"UriPath,omitempty"`
+}
+
+type TextTransformation struct {
+	Priority *IntegerExpr `json:"Priority,omitempty"`
+	Type     *StringExpr  `json:"Type,omitempty"`
+}
+
+type IPSetReferenceStatement struct {
+	Arn *StringExpr `json:"Arn,omitempty"`
+}
+
+type ManagedRuleGroupStatement struct {
+	Name       *StringExpr `json:"Name,omitempty"`
+	VendorName *StringExpr `json:"VendorName,omitempty"`
+}
+
+type OrStatement struct {
+	Statements []WafStatement `json:"Statements,omitempty"`
+}
+
+// RateBasedStatement limits the requests of each client IP over 5 minutes, counting only the requests matched by the
+// scope down statement if there is one.
+type RateBasedStatement struct {
+	AggregateKeyType   *StringExpr   `json:"AggregateKeyType,omitempty"`
+	Limit              *IntegerExpr  `json:"Limit,omitempty"`
+	ScopeDownStatement *WafStatement `json:"ScopeDownStatement,omitempty"`
+}
+
+type VisibilityConfig struct {
+	CloudWatchMetricsEnabled *BoolExpr   `json:"CloudWatchMetricsEnabled,omitempty"`
+	MetricName               *StringExpr `json:"MetricName,omitempty"`
+	SampledRequestsEnabled   *BoolExpr   `json:"SampledRequestsEnabled,omitempty"`
+}
@@ -0,0 +1,4 @@
+package constants
+
+// AccessLogBucketOutputName is looked up by the logs command to find the bucket of the access logs
+var AccessLogBucketOutputName = "OutputAlbAccessLogBucket"
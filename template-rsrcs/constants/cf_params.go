@@ -4,4 +4,5 @@ var MysqlPasswordParamName = "MysqlPassword"
 var DomainNameParamName = "DomainName"
 var CertificateArnParamName = "CertificateArn"
 var Ec2KeyNameParamName = "Ec2KeyName"
+var HostedZoneIdParamName = "HostedZoneId"
 
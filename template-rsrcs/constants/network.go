@@ -7,6 +7,7 @@ var TcpProtocol = "tcp"
 var AllProtocols = "-1"
 
 var AllIps = "0.0.0.0/0"
+var AllIpv6s = "::/0"
 
 var HttpPort int64 = 80
 var HttpsPort int64 = 443
@@ -0,0 +1,106 @@
+package network
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+)
+
+var vpcIdParamName = "VpcId"
+var publicSubnetIdParamNameFormat = "PublicSubnet%dId"
+var appSubnetIdParamNameFormat = "AppSubnet%dId"
+
+// ExistingNetworkResources stand in for the network when the stack is deployed into a VPC that is managed elsewhere.
+// The VPC and each subnet are template parameters, so the template can be reused with other subnets. There is one
+// parameter per subnet, rather than a list, because an EFS mount target is needed in each application subnet.
+type ExistingNetworkResources struct {
+	template *Template
+	vpc      *ExistingVpc
+}
+
+func NewExistingNetworkResources(template *Template, vpc *ExistingVpc) *ExistingNetworkResources {
+	return &ExistingNetworkResources{
+		template: template,
+		vpc:      vpc,
+	}
+}
+
+func (nr *ExistingNetworkResources) AddToTemplate() {
+	nr.template.Parameters[vpcIdParamName] = &Parameter{
+		Description: "Id of the VPC the stack is deployed into",
+		Type:        "AWS::EC2::VPC::Id",
+	}
+
+	for i := range nr.vpc.PublicSubnetIds {
+		nr.template.Parameters[fmt.Sprintf(publicSubnetIdParamNameFormat, i)] = &Parameter{
+			Description: "Id of a public subnet for the load balancer",
+			Type:        "AWS::EC2::Subnet::Id",
+		}
+	}
+
+	for i := range nr.vpc.AppSubnetIds {
+		nr.template.Parameters[fmt.Sprintf(appSubnetIdParamNameFormat, i)] = &Parameter{
+			Description: "Id of a subnet for the ECS hosts and EFS mount targets",
+			Type:        "AWS::EC2::Subnet::Id",
+		}
+	}
+}
+
+func (nr *ExistingNetworkResources) VpcIdRefFunc() RefFunc {
+	return Ref(vpcIdParamName)
+}
+
+func (nr *ExistingNetworkResources) PublicSubnetRefs() *StringListExpr {
+	return nr.subnetRefs(publicSubnetIdParamNameFormat, nr.vpc.PublicSubnetIds)
+}
+
+func (nr *ExistingNetworkResources) AppSubnetRefs() *StringListExpr {
+	return nr.subnetRefs(appSubnetIdParamNameFormat, nr.vpc.AppSubnetIds)
+}
+
+// AppSubnetsArePublic is whether the application subnets were left to default to the public subnets.
+func (nr *ExistingNetworkResources) AppSubnetsArePublic() bool {
+	if len(nr.vpc.AppSubnetIds) != len(nr.vpc.PublicSubnetIds) {
+		return false
+	}
+	for i, subnetId := range nr.vpc.AppSubnetIds {
+		if subnetId != nr.vpc.PublicSubnetIds[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (nr *ExistingNetworkResources) subnetRefs(paramNameFormat string, subnetIds []string) *StringListExpr {
+	var refs []Stringable
+	for i := range subnetIds {
+		refs = append(refs, Ref(fmt.Sprintf(paramNameFormat, i)))
+	}
+
+	return StringList(refs...)
+}
+
+// ExistingVpcParameters are the values of the VPC and subnet parameters, or none if the stack creates its own VPC.
+func ExistingVpcParameters(vpc *ExistingVpc) []*cloudformation.Parameter {
+	if vpc == nil {
+		return nil
+	}
+
+	parameters := []*cloudformation.Parameter{parameter(vpcIdParamName, vpc.VpcId)}
+	for i, subnetId := range vpc.PublicSubnetIds {
+		parameters = append(parameters, parameter(fmt.Sprintf(publicSubnetIdParamNameFormat, i), subnetId))
+	}
+	for i, subnetId := range vpc.AppSubnetIds {
+		parameters = append(parameters, parameter(fmt.Sprintf(appSubnetIdParamNameFormat, i), subnetId))
+	}
+
+	return parameters
+}
+
+func parameter(key string, value string) *cloudformation.Parameter {
+	return &cloudformation.Parameter{
+		ParameterKey:   &key,
+		ParameterValue: &value,
+	}
+}
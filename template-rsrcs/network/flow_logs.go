@@ -0,0 +1,106 @@
+package network
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+func (nr *NetworkResources) flowLogLogicalName() string {
+	return nr.config.CfName("VpcFlowLog")
+}
+
+func (nr *NetworkResources) flowLogGroupLogicalName() string {
+	return nr.config.CfName("VpcFlowLogGroup")
+}
+
+func (nr *NetworkResources) flowLogRoleLogicalName() string {
+	return nr.config.CfName("VpcFlowLogRole")
+}
+
+func (nr *NetworkResources) flowLogBucketLogicalName() string {
+	return nr.config.CfName("VpcFlowLogBucket")
+}
+
+// addFlowLogs records the traffic in the VPC to a log group, or to a bucket, that expires the records after the
+// retention period. The bucket is retained when the stack is deleted, as a bucket that still holds records cannot be
+// deleted.
+func (nr *NetworkResources) addFlowLogs() {
+	flowLogsConfig := nr.config.StageConfig().FlowLogs
+	flowLog := &cf_rsrcs.FlowLog{
+		EC2FlowLog: &EC2FlowLog{
+			ResourceId:   nr.VpcIdRefFunc().String(),
+			ResourceType: String("VPC"),
+			TrafficType:  String(flowLogsConfig.Traffic()),
+		},
+	}
+
+	if flowLogsConfig.IsS3Destination() {
+		nr.template.Resources[nr.flowLogBucketLogicalName()] = &Resource{
+			DeletionPolicy: "Retain",
+			Properties:     cf_rsrcs.PrivateLogBucket(int64(flowLogsConfig.Retention()), nr.config.ResourceTags()),
+		}
+
+		flowLog.LogDestinationType = String("s3")
+		flowLog.LogDestination = GetAtt(nr.flowLogBucketLogicalName(), "Arn")
+	} else {
+		nr.addFlowLogGroup(int64(flowLogsConfig.Retention()))
+
+		flowLog.DeliverLogsPermissionArn = GetAtt(nr.flowLogRoleLogicalName(), "Arn")
+		flowLog.LogGroupName = Ref(nr.flowLogGroupLogicalName()).String()
+	}
+
+	nr.template.AddResource(nr.flowLogLogicalName(), flowLog)
+}
+
+func (nr *NetworkResources) addFlowLogGroup(retentionDays int64) {
+	nr.template.AddResource(
+		nr.flowLogGroupLogicalName(),
+		&cf_rsrcs.LogGroup{
+			LogsLogGroup: &LogsLogGroup{
+				RetentionInDays: Integer(retentionDays),
+			},
+			Tags: nr.config.ResourceTags(),
+		},
+	)
+
+	nr.template.AddResource(
+		nr.flowLogRoleLogicalName(),
+		&IAMRole{
+			AssumeRolePolicyDocument: `{
+                "Statement":[
+                {
+                  "Effect":"Allow",
+                  "Principal":{
+                    "Service":[
+                      "vpc-flow-logs.amazonaws.com"
+                    ]
+                  },
+                  "Action":[
+                    "sts:AssumeRole"
+                  ]
+                }
+              ]
+            }`,
+			Path: String("/"),
+			Policies: &IAMPoliciesList{
+				IAMPolicies{
+					PolicyName: String("vpc-flow-logs-delivery"),
+					PolicyDocument: `{
+                        "Statement":[
+                            {
+                              "Effect":"Allow",
+                              "Action":[
+                                "logs:CreateLogStream",
+                                "logs:DescribeLogGroups",
+                                "logs:DescribeLogStreams",
+                                "logs:PutLogEvents"
+                              ],
+                              "Resource":"*"
+                            }
+                        ]
+                    }`,
+				},
+			},
+		},
+	)
+}
@@ -0,0 +1,18 @@
+package network
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+)
+
+// Network is the VPC and subnets the service runs in, which are either created by the stack or passed in to it.
+type Network interface {
+	AddToTemplate()
+	VpcIdRefFunc() RefFunc
+	// PublicSubnetRefs are the subnets for the load balancer.
+	PublicSubnetRefs() *StringListExpr
+	// AppSubnetRefs are the subnets for the ECS hosts and the EFS mount targets.
+	AppSubnetRefs() *StringListExpr
+	// AppSubnetsArePublic is whether the application subnets are the public subnets, which reach the internet through
+	// the internet gateway rather than NAT gateways.
+	AppSubnetsArePublic() bool
+}
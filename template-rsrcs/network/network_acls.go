@@ -0,0 +1,150 @@
+package network
+
+import (
+	"fmt"
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/constants"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+// network ACLs take protocol numbers rather than names
+var aclAllProtocols int64 = -1
+var aclTcpProtocol int64 = 6
+
+// the ports clients pick for their side of a connection, which the return traffic is addressed to
+var ephemeralPortsFrom int64 = 1024
+var ephemeralPortsTo int64 = 65535
+
+// aclRule is an entry in a network ACL. Network ACLs are stateless, so the return traffic of every connection needs a
+// rule of its own.
+type aclRule struct {
+	name     string
+	number   int64
+	egress   bool
+	protocol int64
+	fromPort int64
+	toPort   int64
+	cidr     string
+	ipv6     bool
+}
+
+func (nr *NetworkResources) networkAclLogicalName(tier subnetTier) string {
+	return nr.config.CfName(fmt.Sprintf("%sNetworkAcl", tier.basename))
+}
+
+// addNetworkAcls gives each subnet tier its own network ACL. The public subnets accept HTTP and HTTPS from the
+// internet, while the private subnets only accept traffic from inside the VPC. Both accept the return traffic of the
+// connections they make to the internet.
+func (nr *NetworkResources) addNetworkAcls() {
+	nr.addNetworkAcl(publicTier, nr.publicAclRules())
+	if nr.hasPrivateSubnets() {
+		nr.addNetworkAcl(privateTier, nr.privateAclRules())
+	}
+}
+
+func (nr *NetworkResources) publicAclRules() []aclRule {
+	rules := []aclRule{
+		{name: "HttpsIn", number: 100, protocol: aclTcpProtocol, fromPort: HttpsPort, toPort: HttpsPort, cidr: AllIps},
+		{name: "HttpIn", number: 110, protocol: aclTcpProtocol, fromPort: HttpPort, toPort: HttpPort, cidr: AllIps},
+		{name: "VpcIn", number: 120, protocol: aclAllProtocols, cidr: nr.layout.VpcCidr},
+		{
+			name: "EphemeralIn", number: 130, protocol: aclTcpProtocol,
+			fromPort: ephemeralPortsFrom, toPort: ephemeralPortsTo, cidr: AllIps,
+		},
+		{name: "AllOut", number: 100, egress: true, protocol: aclAllProtocols, cidr: AllIps},
+	}
+
+	if nr.isDualStack() {
+		rules = append(rules,
+			aclRule{
+				name: "HttpsIpv6In", number: 101, protocol: aclTcpProtocol,
+				fromPort: HttpsPort, toPort: HttpsPort, cidr: AllIpv6s, ipv6: true,
+			},
+			aclRule{
+				name: "HttpIpv6In", number: 111, protocol: aclTcpProtocol,
+				fromPort: HttpPort, toPort: HttpPort, cidr: AllIpv6s, ipv6: true,
+			},
+			aclRule{
+				name: "EphemeralIpv6In", number: 131, protocol: aclTcpProtocol,
+				fromPort: ephemeralPortsFrom, toPort: ephemeralPortsTo, cidr: AllIpv6s, ipv6: true,
+			},
+			aclRule{name: "AllIpv6Out", number: 101, egress: true, protocol: aclAllProtocols, cidr: AllIpv6s, ipv6: true},
+		)
+	}
+
+	// the hosts share the public subnets when there are no private ones, so the admin ranges need to reach them
+	if !nr.hasPrivateSubnets() {
+		for i, adminCidr := range nr.config.StageConfig().AdminCidrs {
+			rules = append(rules, aclRule{
+				name: fmt.Sprintf("Admin%dSshIn", i), number: 200 + int64(i), protocol: aclTcpProtocol,
+				fromPort: SshPort, toPort: SshPort, cidr: adminCidr,
+			})
+		}
+	}
+
+	return rules
+}
+
+func (nr *NetworkResources) privateAclRules() []aclRule {
+	rules := []aclRule{
+		{name: "VpcIn", number: 100, protocol: aclAllProtocols, cidr: nr.layout.VpcCidr},
+		{
+			name: "EphemeralIn", number: 110, protocol: aclTcpProtocol,
+			fromPort: ephemeralPortsFrom, toPort: ephemeralPortsTo, cidr: AllIps,
+		},
+		{name: "AllOut", number: 100, egress: true, protocol: aclAllProtocols, cidr: AllIps},
+	}
+
+	if nr.isDualStack() {
+		rules = append(rules,
+			aclRule{
+				name: "EphemeralIpv6In", number: 111, protocol: aclTcpProtocol,
+				fromPort: ephemeralPortsFrom, toPort: ephemeralPortsTo, cidr: AllIpv6s, ipv6: true,
+			},
+			aclRule{name: "AllIpv6Out", number: 101, egress: true, protocol: aclAllProtocols, cidr: AllIpv6s, ipv6: true},
+		)
+	}
+
+	return rules
+}
+
+func (nr *NetworkResources) addNetworkAcl(tier subnetTier, rules []aclRule) {
+	aclLogicalName := nr.networkAclLogicalName(tier)
+	nr.template.AddResource(
+		aclLogicalName,
+		&EC2NetworkAcl{
+			Tags:  nr.config.ResourceTags(),
+			VpcId: nr.VpcIdRefFunc().String(),
+		},
+	)
+
+	for _, rule := range rules {
+		entry := &cf_rsrcs.NetworkAclEntry{
+			Egress:       Bool(rule.egress),
+			NetworkAclId: Ref(aclLogicalName).String(),
+			Protocol:     Integer(rule.protocol),
+			RuleAction:   String("allow"),
+			RuleNumber:   Integer(rule.number),
+		}
+		if rule.ipv6 {
+			entry.Ipv6CidrBlock = String(rule.cidr)
+		} else {
+			entry.CidrBlock = String(rule.cidr)
+		}
+		if rule.protocol != aclAllProtocols {
+			entry.PortRange = &cf_rsrcs.AclPortRange{From: Integer(rule.fromPort), To: Integer(rule.toPort)}
+		}
+
+		nr.template.AddResource(nr.config.CfName(fmt.Sprintf("%sNetworkAcl%s", tier.basename, rule.name)), entry)
+	}
+
+	for _, sn := range nr.subnetsInTier(tier) {
+		nr.template.AddResource(
+			nr.config.CfName(fmt.Sprintf("%s%dNetworkAclAssoc", sn.tier.basename, sn.index)),
+			&EC2SubnetNetworkAclAssociation{
+				NetworkAclId: Ref(aclLogicalName).String(),
+				SubnetId:     Ref(nr.subnetLogicalName(sn)).String(),
+			},
+		)
+	}
+}
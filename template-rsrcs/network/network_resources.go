@@ -0,0 +1,369 @@
+package network
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/constants"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_funcs"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+// NetworkResources are the VPC, its subnets and the routing for them. The load balancer always sits in the public
+// subnets. The application tier, meaning the ECS hosts and EFS mount targets, sits in the private subnets if the stage
+// is configured with them, otherwise it shares the public subnets. Private subnets without NAT gateways only reach AWS
+// through the VPC endpoints. Dual stack networks route IPv6 out of the public
+// subnets through the internet gateway and out of the private subnets through an egress only internet gateway.
+type NetworkResources struct {
+	template *Template
+	config   *TemplateConfig
+	layout   *SubnetLayout
+	azCount  int
+	subnets  []subnet
+}
+
+func NewNetworkResources(template *Template, config *TemplateConfig, azs []*ec2.AvailabilityZone) *NetworkResources {
+	layout := config.SubnetLayout()
+	azCount := numAzs(config, azs)
+	return &NetworkResources{
+		template: template,
+		config:   config,
+		layout:   layout,
+		azCount:  azCount,
+		subnets:  newSubnets(layout, azs, azCount),
+	}
+}
+
+func (nr *NetworkResources) AddToTemplate() {
+	nr.addVPC()
+	if nr.isDualStack() {
+		nr.addVpcIpv6CidrBlock()
+	}
+	nr.addSubnets()
+	nr.addInternetGateway()
+	nr.addInternetGatewayAttachment()
+	nr.addRouteTable()
+	nr.addPublicRoute()
+	nr.addSubnetRouteTableAssociations()
+
+	if nr.hasPrivateSubnets() {
+		if nr.config.HasNatGateways() {
+			nr.addNatGateways()
+		}
+		nr.addPrivateRouteTables()
+	}
+
+	if nr.isDualStack() {
+		nr.addPublicIpv6Route()
+		if nr.hasPrivateSubnets() {
+			nr.addEgressOnlyInternetGateway()
+			nr.addPrivateIpv6Routes()
+		}
+	}
+
+	nr.addVpcEndpoints()
+
+	if nr.config.StageConfig().NetworkAcls {
+		nr.addNetworkAcls()
+	}
+	if nr.config.StageConfig().FlowLogs != nil {
+		nr.addFlowLogs()
+	}
+}
+
+func (nr *NetworkResources) VpcIdRefFunc() RefFunc {
+	return Ref(nr.vpcLogicalName())
+}
+
+// PublicSubnetRefs are the subnets for the load balancer.
+func (nr *NetworkResources) PublicSubnetRefs() *StringListExpr {
+	return nr.subnetRefs(nr.subnetsInTier(publicTier))
+}
+
+// AppSubnetRefs are the subnets for the ECS hosts and the EFS mount targets.
+func (nr *NetworkResources) AppSubnetRefs() *StringListExpr {
+	if nr.hasPrivateSubnets() {
+		return nr.subnetRefs(nr.subnetsInTier(privateTier))
+	}
+
+	return nr.PublicSubnetRefs()
+}
+
+func (nr *NetworkResources) AppSubnetsArePublic() bool {
+	return !nr.hasPrivateSubnets()
+}
+
+func (nr *NetworkResources) isDualStack() bool {
+	return nr.config.StageConfig().DualStack
+}
+
+func (nr *NetworkResources) hasPrivateSubnets() bool {
+	return len(nr.subnetsInTier(privateTier)) > 0
+}
+
+func (nr *NetworkResources) subnetsInTier(tier subnetTier) []subnet {
+	var tierSubnets []subnet
+	for _, sn := range nr.subnets {
+		if sn.tier == tier {
+			tierSubnets = append(tierSubnets, sn)
+		}
+	}
+
+	return tierSubnets
+}
+
+func (nr *NetworkResources) subnetRefs(subnets []subnet) *StringListExpr {
+	var refs []Stringable
+	for _, sn := range subnets {
+		refs = append(refs, Ref(nr.subnetLogicalName(sn)))
+	}
+
+	return StringList(refs...)
+}
+
+func (nr *NetworkResources) vpcLogicalName() string {
+	return nr.config.CfName("VPC")
+}
+
+func (nr *NetworkResources) subnetLogicalName(sn subnet) string {
+	return nr.config.CfName(fmt.Sprintf("%s%d", sn.tier.basename, sn.index))
+}
+
+func (nr *NetworkResources) vpcIpv6CidrBlockLogicalName() string {
+	return nr.config.CfName("VpcIpv6CidrBlock")
+}
+
+func (nr *NetworkResources) egressOnlyInternetGatewayLogicalName() string {
+	return nr.config.CfName("EgressOnlyInternetGateway")
+}
+
+func (nr *NetworkResources) internetGatewayLogicalName() string {
+	return nr.config.CfName("InternetGateway")
+}
+
+func (nr *NetworkResources) internetGatewayAttachmentLogicalName() string {
+	return nr.config.CfName("VpcInternetGatewayAttachment")
+}
+
+func (nr *NetworkResources) routeTableLogicalName() string {
+	return nr.config.CfName("VpcRouteTable")
+}
+
+func (nr *NetworkResources) privateRouteTableLogicalName(index int) string {
+	return nr.config.CfName(fmt.Sprintf("PrivateRouteTable%d", index))
+}
+
+func (nr *NetworkResources) natGatewayLogicalName(index int) string {
+	return nr.config.CfName(fmt.Sprintf("NatGateway%d", index))
+}
+
+func (nr *NetworkResources) natEipLogicalName(index int) string {
+	return nr.config.CfName(fmt.Sprintf("NatGatewayEip%d", index))
+}
+
+// natGatewayIndex is the index of the public subnet holding the NAT gateway that the subnet routes through.
+func (nr *NetworkResources) natGatewayIndex(sn subnet) int {
+	if nr.config.NatGatewayPerAz() {
+		return sn.index % nr.azCount
+	}
+
+	return 0
+}
+
+func (nr *NetworkResources) addVPC() {
+	nr.template.AddResource(
+		nr.vpcLogicalName(),
+		&EC2VPC{
+			CidrBlock:          String(nr.layout.VpcCidr),
+			EnableDnsHostnames: Bool(true),
+			InstanceTenancy:    String("default"),
+			Tags:               nr.config.ResourceTags(),
+		},
+	)
+}
+
+func (nr *NetworkResources) addVpcIpv6CidrBlock() {
+	nr.template.AddResource(
+		nr.vpcIpv6CidrBlockLogicalName(),
+		&cf_rsrcs.VPCCidrBlock{
+			AmazonProvidedIpv6CidrBlock: Bool(true),
+			VpcId:                       nr.VpcIdRefFunc().String(),
+		},
+	)
+}
+
+func (nr *NetworkResources) addSubnets() {
+	for i, sn := range nr.subnets {
+		subnetProps := &cf_rsrcs.Subnet{
+			EC2Subnet: &EC2Subnet{
+				AvailabilityZone:    String(sn.az),
+				CidrBlock:           String(sn.cidr),
+				MapPublicIpOnLaunch: Bool(sn.tier == publicTier),
+				Tags:                nr.config.ResourceTags(),
+				VpcId:               nr.VpcIdRefFunc().String(),
+			},
+		}
+		var dependsOn []string
+
+		if nr.isDualStack() {
+			// the subnet can only be given an IPv6 block once the VPC has one
+			dependsOn = []string{nr.vpcIpv6CidrBlockLogicalName()}
+			subnetProps.AssignIpv6AddressOnCreation = Bool(true)
+			subnetProps.Ipv6CidrBlock = Ipv6SubnetCidr(nr.vpcLogicalName(), i, len(nr.subnets))
+		}
+
+		nr.template.Resources[nr.subnetLogicalName(sn)] = &Resource{
+			DependsOn:  dependsOn,
+			Properties: subnetProps,
+		}
+	}
+}
+
+func (nr *NetworkResources) addInternetGateway() {
+	nr.template.AddResource(
+		nr.internetGatewayLogicalName(),
+		&EC2InternetGateway{
+			Tags: nr.config.ResourceTags(),
+		},
+	)
+}
+
+func (nr *NetworkResources) addInternetGatewayAttachment() {
+	nr.template.AddResource(
+		nr.internetGatewayAttachmentLogicalName(),
+		&EC2VPCGatewayAttachment{
+			InternetGatewayId: Ref(nr.internetGatewayLogicalName()).String(),
+			VpcId:             nr.VpcIdRefFunc().String(),
+		},
+	)
+}
+
+func (nr *NetworkResources) addRouteTable() {
+	nr.template.AddResource(
+		nr.routeTableLogicalName(),
+		&EC2RouteTable{
+			Tags:  nr.config.ResourceTags(),
+			VpcId: nr.VpcIdRefFunc().String(),
+		},
+	)
+}
+
+func (nr *NetworkResources) addPublicRoute() {
+	route := Resource{
+		DependsOn: []string{nr.internetGatewayAttachmentLogicalName()},
+		Properties: &EC2Route{
+			RouteTableId:         Ref(nr.routeTableLogicalName()).String(),
+			DestinationCidrBlock: String(AllIps),
+			GatewayId:            Ref(nr.internetGatewayLogicalName()).String(),
+		},
+	}
+	nr.template.Resources[nr.config.CfName("PublicRoute")] = &route
+}
+
+func (nr *NetworkResources) addSubnetRouteTableAssociations() {
+	for _, sn := range nr.subnetsInTier(publicTier) {
+		nr.template.AddResource(
+			nr.config.CfName(fmt.Sprintf("Subnet%dRouteTableAssoc", sn.index)),
+			&EC2SubnetRouteTableAssociation{
+				RouteTableId: Ref(nr.routeTableLogicalName()).String(),
+				SubnetId:     Ref(nr.subnetLogicalName(sn)).String(),
+			},
+		)
+	}
+}
+
+// addNatGateways adds a NAT gateway to the public subnet in each availability zone, or only to the first one if the
+// stage shares a single NAT gateway.
+func (nr *NetworkResources) addNatGateways() {
+	for _, sn := range nr.subnetsInTier(publicTier) {
+		if nr.natGatewayIndex(sn) != sn.index {
+			continue
+		}
+
+		nr.template.Resources[nr.natEipLogicalName(sn.index)] = &Resource{
+			DependsOn: []string{nr.internetGatewayAttachmentLogicalName()},
+			Properties: &EC2EIP{
+				Domain: String("vpc"),
+			},
+		}
+
+		nr.template.AddResource(
+			nr.natGatewayLogicalName(sn.index),
+			&EC2NatGateway{
+				AllocationId: GetAtt(nr.natEipLogicalName(sn.index), "AllocationId"),
+				SubnetId:     Ref(nr.subnetLogicalName(sn)).String(),
+			},
+		)
+	}
+}
+
+// addPrivateRouteTables gives each private subnet a route table with a default route through the NAT gateway in the
+// same availability zone, or through the single NAT gateway. Without NAT gateways there is no default route.
+func (nr *NetworkResources) addPrivateRouteTables() {
+	for _, sn := range nr.subnetsInTier(privateTier) {
+		nr.template.AddResource(
+			nr.privateRouteTableLogicalName(sn.index),
+			&EC2RouteTable{
+				Tags:  nr.config.ResourceTags(),
+				VpcId: nr.VpcIdRefFunc().String(),
+			},
+		)
+
+		if nr.config.HasNatGateways() {
+			nr.template.AddResource(
+				nr.config.CfName(fmt.Sprintf("PrivateRoute%d", sn.index)),
+				&EC2Route{
+					RouteTableId:         Ref(nr.privateRouteTableLogicalName(sn.index)).String(),
+					DestinationCidrBlock: String(AllIps),
+					NatGatewayId:         Ref(nr.natGatewayLogicalName(nr.natGatewayIndex(sn))).String(),
+				},
+			)
+		}
+
+		nr.template.AddResource(
+			nr.config.CfName(fmt.Sprintf("PrivateSubnet%dRouteTableAssoc", sn.index)),
+			&EC2SubnetRouteTableAssociation{
+				RouteTableId: Ref(nr.privateRouteTableLogicalName(sn.index)).String(),
+				SubnetId:     Ref(nr.subnetLogicalName(sn)).String(),
+			},
+		)
+	}
+}
+
+func (nr *NetworkResources) addPublicIpv6Route() {
+	nr.template.Resources[nr.config.CfName("PublicIpv6Route")] = &Resource{
+		DependsOn: []string{nr.internetGatewayAttachmentLogicalName()},
+		Properties: &cf_rsrcs.Route{
+			EC2Route: &EC2Route{
+				RouteTableId: Ref(nr.routeTableLogicalName()).String(),
+				GatewayId:    Ref(nr.internetGatewayLogicalName()).String(),
+			},
+			DestinationIpv6CidrBlock: String(AllIpv6s),
+		},
+	}
+}
+
+func (nr *NetworkResources) addEgressOnlyInternetGateway() {
+	nr.template.AddResource(
+		nr.egressOnlyInternetGatewayLogicalName(),
+		&cf_rsrcs.EgressOnlyInternetGateway{
+			VpcId: nr.VpcIdRefFunc().String(),
+		},
+	)
+}
+
+func (nr *NetworkResources) addPrivateIpv6Routes() {
+	for _, sn := range nr.subnetsInTier(privateTier) {
+		nr.template.AddResource(
+			nr.config.CfName(fmt.Sprintf("PrivateIpv6Route%d", sn.index)),
+			&cf_rsrcs.Route{
+				EC2Route: &EC2Route{
+					RouteTableId: Ref(nr.privateRouteTableLogicalName(sn.index)).String(),
+				},
+				DestinationIpv6CidrBlock:    String(AllIpv6s),
+				EgressOnlyInternetGatewayId: Ref(nr.egressOnlyInternetGatewayLogicalName()).String(),
+			},
+		)
+	}
+}
@@ -0,0 +1,63 @@
+package network
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+)
+
+// A subnetTier groups the subnets that share a route table layout. The basename is used in the logical names of the
+// subnets, the public tier keeping the name the subnets had before there were tiers.
+type subnetTier struct {
+	basename string
+}
+
+var publicTier = subnetTier{basename: "Subnet"}
+var privateTier = subnetTier{basename: "PrivateSubnet"}
+
+// A subnet in the VPC. The index is the position of the subnet within its tier, and subnets with the same index are in
+// the same availability zone.
+type subnet struct {
+	tier  subnetTier
+	index int
+	az    string
+	cidr  string
+}
+
+// numAzs is the number of availability zones the subnets are spread over.
+func numAzs(config *TemplateConfig, azs []*ec2.AvailabilityZone) int {
+	stageAzCount := config.StageConfig().AzCount
+	if stageAzCount == 0 {
+		return len(azs)
+	}
+
+	if stageAzCount > len(azs) {
+		panic(fmt.Sprintf(
+			"Stage '%s' wants %d availability zones, but region '%s' only has %d",
+			config.Stage, stageAzCount, config.Region, len(azs),
+		))
+	}
+	return stageAzCount
+}
+
+func newSubnets(layout *SubnetLayout, azs []*ec2.AvailabilityZone, azCount int) []subnet {
+	var subnets []subnet
+	for _, tierCidrs := range []struct {
+		tier  subnetTier
+		cidrs []string
+	}{
+		{publicTier, layout.PublicCidrs},
+		{privateTier, layout.PrivateCidrs},
+	} {
+		for i, cidr := range tierCidrs.cidrs {
+			subnets = append(subnets, subnet{
+				tier:  tierCidrs.tier,
+				index: i,
+				az:    *azs[i%azCount].ZoneName,
+				cidr:  cidr,
+			})
+		}
+	}
+
+	return subnets
+}
@@ -0,0 +1,115 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/constants"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_funcs"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+// addVpcEndpoints adds the endpoints configured for the stage. The S3 gateway endpoint is attached to every route
+// table. Each AWS service behind an interface endpoint gets its own security group, which accepts HTTPS from the VPC,
+// and private DNS so that the hosts reach it through the usual service hostname.
+func (nr *NetworkResources) addVpcEndpoints() {
+	for _, endpoint := range nr.config.StageConfig().VpcEndpoints {
+		if endpoint == S3VpcEndpoint {
+			nr.addS3VpcEndpoint()
+			continue
+		}
+
+		for _, service := range InterfaceVpcEndpoints[endpoint] {
+			nr.addInterfaceVpcEndpoint(service)
+		}
+	}
+}
+
+func (nr *NetworkResources) vpcEndpointLogicalName(service string) string {
+	return nr.config.CfName(fmt.Sprintf("%sVpcEndpoint", serviceLogicalId(service)))
+}
+
+func (nr *NetworkResources) vpcEndpointSecurityGroupLogicalName(service string) string {
+	return nr.config.CfName(fmt.Sprintf("%sVpcEndpointSecurityGroup", serviceLogicalId(service)))
+}
+
+func (nr *NetworkResources) vpcEndpointServiceName(service string) *StringExpr {
+	return Sub(String(fmt.Sprintf("com.amazonaws.${AWS::Region}.%s", service)))
+}
+
+func (nr *NetworkResources) addS3VpcEndpoint() {
+	routeTableRefs := []Stringable{Ref(nr.routeTableLogicalName())}
+	for _, sn := range nr.subnetsInTier(privateTier) {
+		routeTableRefs = append(routeTableRefs, Ref(nr.privateRouteTableLogicalName(sn.index)))
+	}
+
+	nr.template.AddResource(
+		nr.vpcEndpointLogicalName(S3VpcEndpoint),
+		&cf_rsrcs.VPCEndpoint{
+			RouteTableIds:   StringList(routeTableRefs...),
+			ServiceName:     nr.vpcEndpointServiceName(S3VpcEndpoint),
+			VpcEndpointType: String("Gateway"),
+			VpcId:           nr.VpcIdRefFunc().String(),
+		},
+	)
+}
+
+func (nr *NetworkResources) addInterfaceVpcEndpoint(service string) {
+	nr.template.AddResource(
+		nr.vpcEndpointSecurityGroupLogicalName(service),
+		&EC2SecurityGroup{
+			GroupDescription: String(fmt.Sprintf("Security group for the %s VPC endpoint", service)),
+			SecurityGroupIngress: &EC2SecurityGroupRuleList{
+				EC2SecurityGroupRule{
+					CidrIp:     String(nr.layout.VpcCidr),
+					IpProtocol: String(TcpProtocol),
+					FromPort:   Integer(HttpsPort),
+					ToPort:     Integer(HttpsPort),
+				},
+			},
+			Tags:  nr.config.ResourceTags(),
+			VpcId: nr.VpcIdRefFunc().String(),
+		},
+	)
+
+	nr.template.AddResource(
+		nr.vpcEndpointLogicalName(service),
+		&cf_rsrcs.VPCEndpoint{
+			PrivateDnsEnabled: Bool(true),
+			SecurityGroupIds:  StringList(Ref(nr.vpcEndpointSecurityGroupLogicalName(service))),
+			ServiceName:       nr.vpcEndpointServiceName(service),
+			SubnetIds:         nr.subnetRefs(nr.vpcEndpointSubnets()),
+			VpcEndpointType:   String("Interface"),
+			VpcId:             nr.VpcIdRefFunc().String(),
+		},
+	)
+}
+
+// vpcEndpointSubnets are the application subnets to put the interface endpoints in. An interface endpoint can only
+// have one subnet per availability zone, so the subnets beyond the first in each zone are left out.
+func (nr *NetworkResources) vpcEndpointSubnets() []subnet {
+	tier := publicTier
+	if nr.hasPrivateSubnets() {
+		tier = privateTier
+	}
+
+	var endpointSubnets []subnet
+	for _, sn := range nr.subnetsInTier(tier) {
+		if sn.index < nr.azCount {
+			endpointSubnets = append(endpointSubnets, sn)
+		}
+	}
+
+	return endpointSubnets
+}
+
+// serviceLogicalId turns an endpoint service name such as 'ecr.api' into a logical id such as 'EcrApi'.
+func serviceLogicalId(service string) string {
+	words := strings.FieldsFunc(service, func(r rune) bool { return r == '.' || r == '-' })
+	for i, word := range words {
+		words[i] = strings.Title(word)
+	}
+
+	return strings.Join(words, "")
+}
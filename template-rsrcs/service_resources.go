@@ -2,6 +2,7 @@ package template_rsrcs
 
 import (
 	"fmt"
+	"strconv"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	. "github.com/crewjam/go-cloudformation"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
@@ -9,14 +10,16 @@ import (
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_funcs"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/wp"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/network"
 )
 
-var numSubnets = 3
 var volumeSizeGiB = int64(8)
 var ssdVolumeType = "gp2"
 
 type ServiceParameters struct {
-	Config *TemplateConfig
+	Config      *TemplateConfig
+	ExistingVpc *ExistingVpc
 }
 
 func (s *ServiceParameters) AddToTemplate(template *Template) {
@@ -40,19 +43,26 @@ func (s *ServiceParameters) AddToTemplate(template *Template) {
 		Description:           "AWS ACM Certificate ARN",
 		Type:                  "String",
 	}
-	template.Parameters[Ec2KeyNameParamName] = &Parameter{
-		AllowedPattern:        "[a-zA-Z][a-zA-Z0-9-]*",
-		ConstraintDescription: "must begin with a letter and contain only alphanumeric characters",
-		Description:           "AWS EC2 key name for SSH'ing into hosts",
-		Type:                  "String",
+	template.Parameters[HostedZoneIdParamName] = &Parameter{
+		Description: "Route 53 hosted zone of the domain name, which the alias records of the sites are created in",
+		Type:        "AWS::Route53::HostedZone::Id",
+	}
+	if s.Config.UsesKeyPair() {
+		template.Parameters[Ec2KeyNameParamName] = &Parameter{
+			AllowedPattern:        "[a-zA-Z][a-zA-Z0-9-]*",
+			ConstraintDescription: "must begin with a letter and contain only alphanumeric characters",
+			Description:           "AWS EC2 key name for SSH'ing into hosts",
+			Type:                  "String",
+		}
 	}
 }
 
 func (s *ServiceParameters) CloudFormationParameters(
-	dbPassword string, domainName string, certArn string, ec2KeyName string,
+	dbPassword string, domainName string, certArn string, ec2KeyName string, hostedZoneId string,
 ) []*cloudformation.Parameter {
+	hostedZoneId = BareHostedZoneId(hostedZoneId)
 
-	return []*cloudformation.Parameter{
+	parameters := []*cloudformation.Parameter{
 		{
 			ParameterKey:   &MysqlPasswordParamName,
 			ParameterValue: &dbPassword,
@@ -66,47 +76,75 @@ func (s *ServiceParameters) CloudFormationParameters(
 			ParameterValue: &certArn,
 		},
 		{
+			ParameterKey:   &HostedZoneIdParamName,
+			ParameterValue: &hostedZoneId,
+		},
+	}
+
+	if s.Config.UsesKeyPair() {
+		parameters = append(parameters, &cloudformation.Parameter{
 			ParameterKey:   &Ec2KeyNameParamName,
 			ParameterValue: &ec2KeyName,
-		},
+		})
 	}
+
+	return append(parameters, network.ExistingVpcParameters(s.ExistingVpc)...)
 }
 
 type ServiceResources struct {
-	Template            *Template
-	Config              *TemplateConfig
-	AZs                 []*ec2.AvailabilityZone
-	WordPressSubDomains []string
+	Template       *Template
+	Config         *TemplateConfig
+	AZs            []*ec2.AvailabilityZone
+	WordPressSites []*Site
+	// ExistingVpc is the VPC to deploy into, or nil to create one
+	ExistingVpc *ExistingVpc
+	// SiteCertificateArns are the certificates of the sites with domains of their own, keyed by the site name
+	SiteCertificateArns map[string]string
+	network             network.Network
 }
 
 func (s *ServiceResources) AddToTemplate() {
 	s.addParameters()
 
-	s.addVPC()
-	s.addSubnets()
-	s.addEc2IamInstanceProfile()
-	s.addEc2Role()
+	if s.ExistingVpc != nil {
+		s.network = network.NewExistingNetworkResources(s.Template, s.ExistingVpc)
+	} else {
+		s.network = network.NewNetworkResources(s.Template, s.Config, s.AZs)
+	}
+	s.network.AddToTemplate()
+
+	if !s.Config.UsesFargate() {
+		s.addEc2IamInstanceProfile()
+		s.addEc2Role()
+	}
 	s.addEc2SecurityGroup()
-	s.addInternetGateway()
-	s.addInternetGatewayAttachment()
-	s.addRouteTable()
-	s.addPublicRoute()
-	s.addSubnetRouteTableAssociations()
 
+	if s.hasAccessLogs() {
+		s.addAccessLogBucket()
+	}
 	s.addLoadBalancer()
+	if s.hasWaf() {
+		s.addWebAcl()
+	}
 	s.addLoadBalancerSecurityGroup()
+	if s.isDualStack() {
+		s.addIpv6SecurityGroupRules()
+	}
 	s.addEfsVolume()
 	s.addEfsMountTargets()
 
 	// add wp stuff here
 	wpResources := wp.NewWordPressResources(
-		s.Template, s.Config, s.elbLogicalName(), s.WordPressSubDomains,
-		Ref(s.vpcLogicalName()), s.ec2SecurityGroupRefStringExpr(), Ref(s.elbSecurityGroupLogicalName()).String(),
+		s.Template, s.Config, s.elbLogicalName(), s.WordPressSites,
+		s.vpcIdRefFunc(), s.ec2SecurityGroupRefStringExpr(), Ref(s.elbSecurityGroupLogicalName()).String(),
+		s.SiteCertificateArns, s.fargateResources(),
 	)
 	wpResources.AddToTemplate()
 
-	s.addLaunchConfiguration(wpResources.EcsClusterLogicalName())
-	s.addAsg()
+	if !s.Config.UsesFargate() {
+		s.addLaunchConfiguration(wpResources.EcsClusterLogicalName())
+		s.addAsg()
+	}
 
 	s.addOutputs()
 }
@@ -123,6 +161,10 @@ func (s *ServiceResources) addOutputs() {
 	}
 }
 
+func (s *ServiceResources) isDualStack() bool {
+	return s.Config.StageConfig().DualStack
+}
+
 func (s *ServiceResources) elbLogicalName() string {
 	return s.Config.CfName("AppLoadBalancer")
 }
@@ -147,69 +189,132 @@ func (s *ServiceResources) ec2IamRoleLogicalName() string {
 	return s.Config.CfName("Ec2IamRole")
 }
 
-func (s *ServiceResources) vpcLogicalName() string {
-	return s.Config.CfName("VPC")
+func (s *ServiceResources) launchConfigLogicalName() string {
+	return s.Config.CfName("EcsLaunchConfig")
 }
 
-func (s *ServiceResources) subnetLogicalName(index int) string {
-	return s.Config.CfName(fmt.Sprintf("Subnet%d", index))
+func (s *ServiceResources) efsLogicalName() string {
+	return s.Config.CfName("Efs")
 }
 
-func (s *ServiceResources) internetGatewayLogicalName() string {
-	return s.Config.CfName("InternetGateway")
+func (s *ServiceResources) efsMountTargetLogicalName(index int) string {
+	return s.Config.CfName(fmt.Sprintf("%s%d", "EC2MountTarget", index))
 }
 
-func (s *ServiceResources) internetGatewayAttachmentLogicalName() string {
-	return s.Config.CfName("VpcInternetGatewayAttachment")
+// fargateResources are what the tasks are wired to on Fargate, where they sit in the subnets of the hosts they replace.
+// Tasks in public subnets need a public IP to pull their images, as those subnets have no NAT gateway.
+func (s *ServiceResources) fargateResources() *wp.FargateResources {
+	if !s.Config.UsesFargate() {
+		return nil
+	}
+
+	var mountTargetLogicalNames []string
+	for i := range s.appSubnetRefs().Literal {
+		mountTargetLogicalNames = append(mountTargetLogicalNames, s.efsMountTargetLogicalName(i))
+	}
+
+	return &wp.FargateResources{
+		EfsLogicalName:             s.efsLogicalName(),
+		EfsMountTargetLogicalNames: mountTargetLogicalNames,
+		SubnetRefs:                 s.appSubnetRefs(),
+		AssignPublicIp:             s.network.AppSubnetsArePublic(),
+	}
 }
 
-func (s *ServiceResources) routeTableLogicalName() string {
-	return s.Config.CfName("VpcRouteTable")
+func (s *ServiceResources) vpcIdRefFunc() RefFunc {
+	return s.network.VpcIdRefFunc()
 }
 
-func (s *ServiceResources) launchConfigLogicalName() string {
-	return s.Config.CfName("EcsLaunchConfig")
+// subnetRefs are the public subnets, which the load balancer sits in.
+func (s *ServiceResources) subnetRefs() *StringListExpr {
+	return s.network.PublicSubnetRefs()
 }
 
-func (s *ServiceResources) efsLogicalName() string {
-	return s.Config.CfName("Efs")
+// appSubnetRefs are the subnets the ECS hosts and EFS mount targets sit in.
+func (s *ServiceResources) appSubnetRefs() *StringListExpr {
+	return s.network.AppSubnetRefs()
 }
 
-func (s *ServiceResources) subnetRefs() *StringListExpr {
-	var subnets []Stringable
-	for i := 0; i < numSubnets; i++ {
-		subnets = append(subnets, Ref(s.subnetLogicalName(i)))
+func (s *ServiceResources) addLoadBalancer() {
+	var ipAddressType *StringExpr
+	if s.isDualStack() {
+		ipAddressType = String("dualstack")
+	}
+
+	settings := s.Config.LoadBalancerSettings()
+	attributes := ElasticLoadBalancingLoadBalancerLoadBalancerAttributesList{
+		ElasticLoadBalancingLoadBalancerLoadBalancerAttributes{
+			Key:   String("idle_timeout.timeout_seconds"),
+			Value: String(strconv.Itoa(settings.IdleTimeoutSeconds)),
+		},
+		ElasticLoadBalancingLoadBalancerLoadBalancerAttributes{
+			Key:   String("routing.http2.enabled"),
+			Value: String(strconv.FormatBool(!settings.DisableHttp2)),
+		},
+		ElasticLoadBalancingLoadBalancerLoadBalancerAttributes{
+			Key:   String("routing.http.drop_invalid_header_fields.enabled"),
+			Value: String(strconv.FormatBool(settings.DropInvalidHeaderFields)),
+		},
 	}
 
-	return StringList(subnets...)
+	// the load balancer checks that it can write to the bucket when access logging is turned on
+	var dependsOn []string
+	if s.hasAccessLogs() {
+		attributes = append(attributes, s.accessLogAttributes()...)
+		dependsOn = append(dependsOn, s.accessLogBucketPolicyLogicalName())
+	}
+
+	s.Template.Resources[s.elbLogicalName()] = &Resource{
+		DependsOn: dependsOn,
+		Properties: &cf_rsrcs.LoadBalancer{
+			ElasticLoadBalancingV2LoadBalancer: &ElasticLoadBalancingV2LoadBalancer{
+				LoadBalancerAttributes: &attributes,
+				Name:                   String(s.Config.CfName("WordPressLoadBalancer")),
+				SecurityGroups:         StringList(Ref(s.elbSecurityGroupLogicalName()).String()),
+				Subnets:                s.subnetRefs(),
+				Tags:                   s.Config.ResourceTags(),
+			},
+			IpAddressType: ipAddressType,
+		},
+	}
 }
 
-func (s *ServiceResources) addLoadBalancerRecordSet() {
+// addIpv6SecurityGroupRules lets the load balancer accept IPv6 clients and the hosts reach the internet over IPv6.
+func (s *ServiceResources) addIpv6SecurityGroupRules() {
 	s.Template.AddResource(
-		s.Config.CfName("RecordSetForDomainName"),
-		&Route53RecordSet{
-			AliasTarget: &Route53AliasTargetProperty{
-				DNSName:      GetAtt(s.elbLogicalName(), "DNSName"),
-				HostedZoneId: GetAtt(s.elbLogicalName(), "CanonicalHostedZoneID"),
+		s.Config.CfName("LBSecurityGroupIpv6HttpsIngress"),
+		&cf_rsrcs.SecurityGroupIngress{
+			EC2SecurityGroupIngress: &EC2SecurityGroupIngress{
+				GroupId:    Ref(s.elbSecurityGroupLogicalName()).String(),
+				IpProtocol: String(TcpProtocol),
+				FromPort:   Integer(HttpsPort),
+				ToPort:     Integer(HttpsPort),
 			},
-			Name: Ref(DomainNameParamName).String(),
+			CidrIpv6: String(AllIpv6s),
 		},
 	)
-}
 
-func (s *ServiceResources) addLoadBalancer() {
 	s.Template.AddResource(
-		s.elbLogicalName(),
-		&ElasticLoadBalancingV2LoadBalancer{
-			LoadBalancerAttributes: &ElasticLoadBalancingLoadBalancerLoadBalancerAttributesList{
-				ElasticLoadBalancingLoadBalancerLoadBalancerAttributes{
-					Key:   String("idle_timeout.timeout_seconds"),
-					Value: String("30"),
-				},
+		s.Config.CfName("LBSecurityGroupIpv6HttpIngress"),
+		&cf_rsrcs.SecurityGroupIngress{
+			EC2SecurityGroupIngress: &EC2SecurityGroupIngress{
+				GroupId:    Ref(s.elbSecurityGroupLogicalName()).String(),
+				IpProtocol: String(TcpProtocol),
+				FromPort:   Integer(HttpPort),
+				ToPort:     Integer(HttpPort),
 			},
-			Name:           String(s.Config.CfName("WordPressLoadBalancer")),
-			SecurityGroups: StringList(Ref(s.elbSecurityGroupLogicalName()).String()),
-			Subnets:        s.subnetRefs(),
+			CidrIpv6: String(AllIpv6s),
+		},
+	)
+
+	s.Template.AddResource(
+		s.Config.CfName("Ec2SecurityGroupIpv6Egress"),
+		&cf_rsrcs.SecurityGroupEgress{
+			EC2SecurityGroupEgress: &EC2SecurityGroupEgress{
+				GroupId:    s.ec2SecurityGroupRefStringExpr(),
+				IpProtocol: String(AllProtocols),
+			},
+			CidrIpv6: String(AllIpv6s),
 		},
 	)
 }
@@ -229,31 +334,36 @@ func (s *ServiceResources) addLoadBalancerSecurityGroup() {
 				EC2SecurityGroupRule{
 					CidrIp:     String(AllIps),
 					IpProtocol: String(TcpProtocol),
-					FromPort:   Integer(SshPort),
-					ToPort:     Integer(SshPort),
+					FromPort:   Integer(HttpsPort),
+					ToPort:     Integer(HttpsPort),
 				},
+				// plain HTTP is only accepted to be redirected to HTTPS
 				EC2SecurityGroupRule{
 					CidrIp:     String(AllIps),
 					IpProtocol: String(TcpProtocol),
-					FromPort:   Integer(HttpsPort),
-					ToPort:     Integer(HttpsPort),
+					FromPort:   Integer(HttpPort),
+					ToPort:     Integer(HttpPort),
 				},
 			},
-			VpcId: Ref(s.vpcLogicalName()).String(),
+			Tags:  s.Config.ResourceTags(),
+			VpcId: s.vpcIdRefFunc().String(),
 		},
 	)
 }
 
 func (s *ServiceResources) addAsg() {
+	instances := s.Config.InstanceSettings()
 	s.Template.AddResource(
 		s.Config.CfName("AutoScalingGroup"),
-		&AutoScalingAutoScalingGroup{
-			AvailabilityZones:       GetAZs(s.Config.Region.StringExpr()),
-			DesiredCapacity:         String("1"),
-			LaunchConfigurationName: Ref(s.launchConfigLogicalName()).String(),
-			MinSize:                 String("1"),
-			MaxSize:                 String("1"),
-			VPCZoneIdentifier:       s.subnetRefs(),
+		&cf_rsrcs.AutoScalingGroup{
+			AutoScalingAutoScalingGroup: &AutoScalingAutoScalingGroup{
+				DesiredCapacity:         String(strconv.Itoa(instances.DesiredCapacity)),
+				LaunchConfigurationName: Ref(s.launchConfigLogicalName()).String(),
+				MinSize:                 String(strconv.Itoa(instances.MinSize)),
+				MaxSize:                 String(strconv.Itoa(instances.MaxSize)),
+				VPCZoneIdentifier:       s.appSubnetRefs(),
+			},
+			Tags: cf_rsrcs.AutoScalingTags(s.Config.ResourceTags()),
 		},
 	)
 }
@@ -275,23 +385,45 @@ func (s *ServiceResources) addLaunchConfiguration(ecsClusterLogicalName string)
 			// see ECS optimized AMIs: http://docs.aws.amazon.com/AmazonECS/latest/developerguide/ecs-optimized_AMI.html
 			ImageId:            String("ami-7114c909"),
 			InstanceMonitoring: Bool(false),
-			InstanceType:       String("t2.micro"),
-			KeyName:            Ref(Ec2KeyNameParamName).String(),
+			InstanceType:       String(s.Config.InstanceSettings().Type),
+			KeyName:            s.keyName(),
 			SecurityGroups:     []interface{}{s.ec2SecurityGroupRefStringExpr()},
-			UserData: Base64(Sub(String(fmt.Sprintf(
-				"#!/bin/bash -xe\n"+
-					"echo ECS_CLUSTER=${%s} >> /etc/ecs/ecs.config\n"+
-					"yum install -y aws-cfn-bootstrap nfs-utils\n"+
-					"mkdir -p /mnt/efs/\n"+
-					"chown ec2-user:ec2-user /mnt/efs/\n"+
-					"mount -t nfs -o nfsvers=4.1,rsize=1048576,wsize=1048576,hard,timeo=600,retrans=2 ${%s}.efs.${AWS::Region}.amazonaws.com:/ /mnt/efs/\n"+
-					"/opt/aws/bin/cfn-signal -e $? --stack ${AWS::StackName} --region ${AWS::Region} --resource ECSAutoScalingGroup\n",
-				ecsClusterLogicalName, s.efsLogicalName(),
-			)))),
+			UserData:           Base64(Sub(String(s.userData(ecsClusterLogicalName)))),
 		},
 	)
 }
 
+func (s *ServiceResources) userData(ecsClusterLogicalName string) string {
+	userData := fmt.Sprintf(
+		"#!/bin/bash -xe\n"+
+			"echo ECS_CLUSTER=${%s} >> /etc/ecs/ecs.config\n"+
+			"yum install -y aws-cfn-bootstrap nfs-utils\n",
+		ecsClusterLogicalName,
+	)
+
+	// the ECS optimized AMI neither ships nor starts the agent that Session Manager reaches the hosts through
+	if !s.Config.UsesKeyPair() {
+		userData += "yum install -y amazon-ssm-agent\n" +
+			"start amazon-ssm-agent\n"
+	}
+
+	return userData + fmt.Sprintf(
+		"mkdir -p /mnt/efs/\n"+
+			"chown ec2-user:ec2-user /mnt/efs/\n"+
+			"mount -t nfs -o nfsvers=4.1,rsize=1048576,wsize=1048576,hard,timeo=600,retrans=2 ${%s}.efs.${AWS::Region}.amazonaws.com:/ /mnt/efs/\n"+
+			"/opt/aws/bin/cfn-signal -e $? --stack ${AWS::StackName} --region ${AWS::Region} --resource ECSAutoScalingGroup\n",
+		s.efsLogicalName(),
+	)
+}
+
+func (s *ServiceResources) keyName() *StringExpr {
+	if !s.Config.UsesKeyPair() {
+		return nil
+	}
+
+	return Ref(Ec2KeyNameParamName).String()
+}
+
 func (s *ServiceResources) addEc2IamInstanceProfile() {
 	s.Template.AddResource(
 		s.ec2InstanceProfileLogicalName(),
@@ -320,7 +452,8 @@ func (s *ServiceResources) addEc2Role() {
                 }
               ]
             }`,
-			Path: String("/"),
+			ManagedPolicyArns: s.ec2ManagedPolicyArns(),
+			Path:              String("/"),
 			Policies: &IAMPoliciesList{
 				IAMPolicies{
 					PolicyName: String("ec2-ecs-service-access"),
@@ -349,6 +482,15 @@ func (s *ServiceResources) addEc2Role() {
 	)
 }
 
+// ec2ManagedPolicyArns lets the SSM agent on the hosts register with Session Manager when the hosts have no key pair.
+func (s *ServiceResources) ec2ManagedPolicyArns() *StringListExpr {
+	if s.Config.UsesKeyPair() {
+		return nil
+	}
+
+	return StringList(Sub(String("arn:${AWS::Partition}:iam::aws:policy/AmazonSSMManagedInstanceCore")))
+}
+
 func (s *ServiceResources) addEc2SecurityGroup() {
 	s.Template.AddResource(
 		s.ec2SecurityGroupLogicalName(),
@@ -360,15 +502,9 @@ func (s *ServiceResources) addEc2SecurityGroup() {
 					IpProtocol: String(AllProtocols),
 				},
 			},
-			SecurityGroupIngress: &EC2SecurityGroupRuleList{
-				EC2SecurityGroupRule{
-					CidrIp:     String(AllIps),
-					IpProtocol: String(TcpProtocol),
-					FromPort:   Integer(SshPort),
-					ToPort:     Integer(SshPort),
-				},
-			},
-			VpcId: Ref(s.vpcLogicalName()).String(),
+			SecurityGroupIngress: s.sshIngressRules(),
+			Tags:                 s.Config.ResourceTags(),
+			VpcId:                s.vpcIdRefFunc().String(),
 		},
 	)
 
@@ -396,97 +532,41 @@ func (s *ServiceResources) addEc2SecurityGroup() {
 	)
 }
 
-func (s *ServiceResources) addVPC() {
-	s.Template.AddResource(
-		s.vpcLogicalName(),
-		&EC2VPC{
-			CidrBlock:          String("10.0.0.0/16"),
-			EnableDnsHostnames: Bool(true),
-			InstanceTenancy:    String("default"),
-		},
-	)
-}
-
-func (s *ServiceResources) addSubnets() {
-	for i := 0; i < numSubnets; i++ {
-		s.Template.AddResource(
-			s.subnetLogicalName(i),
-			&EC2Subnet{
-				AvailabilityZone:    String(*s.AZs[i%len(s.AZs)].ZoneName),
-				CidrBlock:           String(fmt.Sprintf("10.0.%d.0/24", i)),
-				MapPublicIpOnLaunch: Bool(true),
-				VpcId:               Ref(s.vpcLogicalName()).String(),
-			},
-		)
+// sshIngressRules let the admin ranges SSH into the hosts. Without any admin ranges the hosts accept no SSH at all.
+func (s *ServiceResources) sshIngressRules() *EC2SecurityGroupRuleList {
+	var rules EC2SecurityGroupRuleList
+	for _, adminCidr := range s.Config.StageConfig().AdminCidrs {
+		rules = append(rules, EC2SecurityGroupRule{
+			CidrIp:     String(adminCidr),
+			IpProtocol: String(TcpProtocol),
+			FromPort:   Integer(SshPort),
+			ToPort:     Integer(SshPort),
+		})
 	}
-}
-
-func (s *ServiceResources) addInternetGateway() {
-	s.Template.AddResource(
-		s.internetGatewayLogicalName(),
-		&EC2InternetGateway{
-			Tags: []ResourceTag{{Key: String("StackName"), Value: Ref("AWS::StackName").String()}},
-		},
-	)
-}
-
-func (s *ServiceResources) addInternetGatewayAttachment() {
-	s.Template.AddResource(
-		s.internetGatewayAttachmentLogicalName(),
-		&EC2VPCGatewayAttachment{
-			InternetGatewayId: Ref(s.internetGatewayLogicalName()).String(),
-			VpcId:             Ref(s.vpcLogicalName()).String(),
-		},
-	)
-}
 
-func (s *ServiceResources) addRouteTable() {
-	s.Template.AddResource(
-		s.routeTableLogicalName(),
-		&EC2RouteTable{
-			VpcId: Ref(s.vpcLogicalName()).String(),
-		},
-	)
-}
-
-func (s *ServiceResources) addPublicRoute() {
-	route := Resource{
-		DependsOn: []string{s.internetGatewayAttachmentLogicalName()},
-		Properties: &EC2Route{
-			RouteTableId:         Ref(s.routeTableLogicalName()).String(),
-			DestinationCidrBlock: String(AllIps),
-			GatewayId:            Ref(s.internetGatewayLogicalName()).String(),
-		},
-	}
-	s.Template.Resources[s.Config.CfName("PublicRoute")] = &route
-}
-
-func (s *ServiceResources) addSubnetRouteTableAssociations() {
-	for i, subnetRef := range s.subnetRefs().Literal {
-		s.Template.AddResource(
-			s.Config.CfName(fmt.Sprintf("Subnet%dRouteTableAssoc", i)),
-			&EC2SubnetRouteTableAssociation{
-				RouteTableId: Ref(s.routeTableLogicalName()).String(),
-				SubnetId:     subnetRef,
-			},
-		)
+	if len(rules) == 0 {
+		return nil
 	}
+	return &rules
 }
 
 func (s *ServiceResources) addEfsVolume() {
 	s.Template.AddResource(
 		s.efsLogicalName(),
-		&EFSFileSystem{
-			PerformanceMode: String("generalPurpose"),
+		&cf_rsrcs.FileSystem{
+			EFSFileSystem: &EFSFileSystem{
+				PerformanceMode: String("generalPurpose"),
+			},
+			FileSystemTags: s.Config.ResourceTags(),
 		},
 	)
 }
 
 func (s *ServiceResources) addEfsMountTargets() {
 	fileSystemId := Ref(s.efsLogicalName()).String()
-	for i, subnetRef := range s.subnetRefs().Literal {
+	for i, subnetRef := range s.appSubnetRefs().Literal {
 		s.Template.AddResource(
-			s.Config.CfName(fmt.Sprintf("%s%d", "EC2MountTarget", i)),
+			s.efsMountTargetLogicalName(i),
 			&EFSMountTarget{
 				FileSystemId:   fileSystemId,
 				SecurityGroups: StringList(Ref(s.ec2SecurityGroupLogicalName())),
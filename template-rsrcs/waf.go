@@ -0,0 +1,178 @@
+package template_rsrcs
+
+import (
+	"fmt"
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+// the web ACL of a load balancer is regional, as opposed to the web ACLs of CloudFront distributions
+var regionalWafScope = "REGIONAL"
+
+func (s *ServiceResources) hasWaf() bool {
+	return s.Config.StageConfig().Waf != nil
+}
+
+func (s *ServiceResources) webAclLogicalName() string {
+	return s.Config.CfName("WebAcl")
+}
+
+func (s *ServiceResources) blockedIpSetLogicalName(ipVersion string) string {
+	return s.Config.CfName(fmt.Sprintf("WafBlocked%sSet", ipVersion))
+}
+
+// addWebAcl puts a web ACL in front of the load balancer. The blocklist is checked first, then the rate limit on the
+// login paths and finally the AWS managed rule groups. The blocklist always blocks, as its ranges are chosen by hand.
+// In count mode the other rules do not block, so their matches can be reviewed in the metrics and sampled requests
+// before switching to block mode.
+func (s *ServiceResources) addWebAcl() {
+	wafConfig := s.Config.StageConfig().Waf
+
+	var rules []cf_rsrcs.WafRule
+	for _, ipSet := range []struct {
+		version string
+		cidrs   []string
+	}{
+		{"IPV4", wafConfig.BlockedIpv4Cidrs()},
+		{"IPV6", wafConfig.BlockedIpv6Cidrs()},
+	} {
+		if len(ipSet.cidrs) == 0 {
+			continue
+		}
+
+		s.addBlockedIpSet(ipSet.version, ipSet.cidrs)
+		rules = append(rules, s.wafRule(
+			fmt.Sprintf("Blocked%s", ipSet.version),
+			&cf_rsrcs.WafAction{Block: &cf_rsrcs.EmptyObject{}},
+			&cf_rsrcs.WafStatement{
+				IPSetReferenceStatement: &cf_rsrcs.IPSetReferenceStatement{
+					Arn: GetAtt(s.blockedIpSetLogicalName(ipSet.version), "Arn"),
+				},
+			},
+		))
+	}
+
+	rules = append(rules, s.wafRule(
+		"LoginRateLimit",
+		s.wafRuleAction(),
+		&cf_rsrcs.WafStatement{
+			RateBasedStatement: &cf_rsrcs.RateBasedStatement{
+				AggregateKeyType:   String("IP"),
+				Limit:              Integer(int64(wafConfig.RateLimit())),
+				ScopeDownStatement: loginPathsStatement(),
+			},
+		},
+	))
+
+	for _, ruleGroup := range WafManagedRuleGroups {
+		rules = append(rules, cf_rsrcs.WafRule{
+			Name:           String(ruleGroup),
+			OverrideAction: s.wafOverrideAction(),
+			Statement: &cf_rsrcs.WafStatement{
+				ManagedRuleGroupStatement: &cf_rsrcs.ManagedRuleGroupStatement{
+					Name:       String(ruleGroup),
+					VendorName: String("AWS"),
+				},
+			},
+			VisibilityConfig: s.wafVisibilityConfig(ruleGroup),
+		})
+	}
+
+	for priority := range rules {
+		rules[priority].Priority = Integer(int64(priority))
+	}
+
+	s.Template.AddResource(
+		s.webAclLogicalName(),
+		&cf_rsrcs.WebACL{
+			DefaultAction:    &cf_rsrcs.WafAction{Allow: &cf_rsrcs.EmptyObject{}},
+			Name:             String(s.Config.CfName("WordPressWebAcl")),
+			Rules:            rules,
+			Scope:            String(regionalWafScope),
+			Tags:             s.Config.ResourceTags(),
+			VisibilityConfig: s.wafVisibilityConfig("WebAcl"),
+		},
+	)
+
+	s.Template.AddResource(
+		s.Config.CfName("WebAclAssociation"),
+		&cf_rsrcs.WebACLAssociation{
+			ResourceArn: Ref(s.elbLogicalName()).String(),
+			WebACLArn:   GetAtt(s.webAclLogicalName(), "Arn"),
+		},
+	)
+}
+
+func (s *ServiceResources) addBlockedIpSet(ipVersion string, cidrs []string) {
+	var addresses []Stringable
+	for _, cidr := range cidrs {
+		addresses = append(addresses, String(cidr))
+	}
+
+	s.Template.AddResource(
+		s.blockedIpSetLogicalName(ipVersion),
+		&cf_rsrcs.IPSet{
+			Addresses:        StringList(addresses...),
+			IPAddressVersion: String(ipVersion),
+			Name:             String(s.Config.CfName(fmt.Sprintf("WordPressBlocked%s", ipVersion))),
+			Scope:            String(regionalWafScope),
+			Tags:             s.Config.ResourceTags(),
+		},
+	)
+}
+
+// wafRule is a rule that takes the action on the requests matching the statement.
+func (s *ServiceResources) wafRule(
+	name string, action *cf_rsrcs.WafAction, statement *cf_rsrcs.WafStatement,
+) cf_rsrcs.WafRule {
+	return cf_rsrcs.WafRule{
+		Action:           action,
+		Name:             String(name),
+		Statement:        statement,
+		VisibilityConfig: s.wafVisibilityConfig(name),
+	}
+}
+
+// wafRuleAction blocks the requests in block mode and counts them otherwise.
+func (s *ServiceResources) wafRuleAction() *cf_rsrcs.WafAction {
+	if s.Config.StageConfig().Waf.IsBlocking() {
+		return &cf_rsrcs.WafAction{Block: &cf_rsrcs.EmptyObject{}}
+	}
+	return &cf_rsrcs.WafAction{Count: &cf_rsrcs.EmptyObject{}}
+}
+
+// wafOverrideAction keeps the actions of a rule group's rules in block mode and turns them into counts otherwise.
+func (s *ServiceResources) wafOverrideAction() *cf_rsrcs.WafAction {
+	if s.Config.StageConfig().Waf.IsBlocking() {
+		return &cf_rsrcs.WafAction{None: &cf_rsrcs.EmptyObject{}}
+	}
+	return &cf_rsrcs.WafAction{Count: &cf_rsrcs.EmptyObject{}}
+}
+
+func (s *ServiceResources) wafVisibilityConfig(metricName string) *cf_rsrcs.VisibilityConfig {
+	return &cf_rsrcs.VisibilityConfig{
+		CloudWatchMetricsEnabled: Bool(true),
+		MetricName:               String(s.Config.CfName(metricName)),
+		SampledRequestsEnabled:   Bool(true),
+	}
+}
+
+// loginPathsStatement matches the requests for any of the login paths, whatever their case.
+func loginPathsStatement() *cf_rsrcs.WafStatement {
+	var statements []cf_rsrcs.WafStatement
+	for _, path := range WafLoginPaths {
+		statements = append(statements, cf_rsrcs.WafStatement{
+			ByteMatchStatement: &cf_rsrcs.ByteMatchStatement{
+				FieldToMatch:         &cf_rsrcs.FieldToMatch{UriPath: &cf_rsrcs.EmptyObject{}},
+				PositionalConstraint: String("EXACTLY"),
+				SearchString:         String(path),
+				TextTransformations: []cf_rsrcs.TextTransformation{
+					{Priority: Integer(0), Type: String("LOWERCASE")},
+				},
+			},
+		})
+	}
+
+	return &cf_rsrcs.WafStatement{OrStatement: &cf_rsrcs.OrStatement{Statements: statements}}
+}
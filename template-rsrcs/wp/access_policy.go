@@ -0,0 +1,77 @@
+package wp
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+// forwardActions sign the visitor in before forwarding to the site if the stage's access policy authenticates.
+func (wpr *wpSubdomainResource) forwardActions() []cf_rsrcs.ListenerAction {
+	forward := cf_rsrcs.ListenerAction{
+		TargetGroupArn: wpr.elbTargetGroupRef(),
+		Type:           String("forward"),
+	}
+
+	accessPolicy := wpr.config.StageConfig().AccessPolicy
+	if accessPolicy == nil || !accessPolicy.IsAuthenticating() {
+		return []cf_rsrcs.ListenerAction{forward}
+	}
+
+	authenticate := cf_rsrcs.ListenerAction{Order: Integer(1)}
+	if accessPolicy.Type == CognitoAccessPolicy {
+		authenticate.Type = String("authenticate-cognito")
+		authenticate.AuthenticateCognitoConfig = &cf_rsrcs.AuthenticateCognitoConfig{
+			UserPoolArn:      String(accessPolicy.Cognito.UserPoolArn),
+			UserPoolClientId: String(accessPolicy.Cognito.UserPoolClientId),
+			UserPoolDomain:   String(accessPolicy.Cognito.UserPoolDomain),
+		}
+	} else {
+		authenticate.Type = String("authenticate-oidc")
+		authenticate.AuthenticateOidcConfig = &cf_rsrcs.AuthenticateOidcConfig{
+			AuthorizationEndpoint: String(accessPolicy.Oidc.AuthorizationEndpoint),
+			ClientId:              String(accessPolicy.Oidc.ClientId),
+			ClientSecret:          String(accessPolicy.Oidc.ClientSecret),
+			Issuer:                String(accessPolicy.Oidc.Issuer),
+			TokenEndpoint:         String(accessPolicy.Oidc.TokenEndpoint),
+			UserInfoEndpoint:      String(accessPolicy.Oidc.UserInfoEndpoint),
+		}
+	}
+
+	forward.Order = Integer(2)
+	return []cf_rsrcs.ListenerAction{authenticate, forward}
+}
+
+// forwardConditions match the hosts of the site and, if the stage's access policy is an IP allowlist, the allowed
+// ranges. Requests from anywhere else fall through to the listener's default action.
+func (wpr *wpSubdomainResource) forwardConditions() []cf_rsrcs.ListenerRuleCondition {
+	conditions := []cf_rsrcs.ListenerRuleCondition{
+		wpr.hostHeaderCondition(wpr.config.SiteHostLabels(wpr.site)...),
+	}
+
+	accessPolicy := wpr.config.StageConfig().AccessPolicy
+	if accessPolicy != nil && accessPolicy.Type == IpAllowlistAccessPolicy {
+		conditions = append(conditions, cf_rsrcs.ListenerRuleCondition{
+			Field:          String("source-ip"),
+			SourceIpConfig: &cf_rsrcs.SourceIpConfig{Values: stringList(accessPolicy.AllowedCidrs)},
+		})
+	}
+
+	return conditions
+}
+
+// elbListenerDefaultActions answer the requests no rule matched with a 404, so that the listener does not depend on any
+// one site. With an access policy they get a 403, as they may also come from outside the allowed ranges.
+func (wprs *WordPressResources) elbListenerDefaultActions() []cf_rsrcs.ListenerAction {
+	fixedResponse := &cf_rsrcs.FixedResponseConfig{
+		ContentType: String("text/plain"),
+		MessageBody: String("Not Found"),
+		StatusCode:  String("404"),
+	}
+	if wprs.config.StageConfig().AccessPolicy != nil {
+		fixedResponse.MessageBody = String("Forbidden")
+		fixedResponse.StatusCode = String("403")
+	}
+
+	return []cf_rsrcs.ListenerAction{{FixedResponseConfig: fixedResponse, Type: String("fixed-response")}}
+}
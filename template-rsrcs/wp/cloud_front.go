@@ -0,0 +1,158 @@
+package wp
+
+import (
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/constants"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+var loadBalancerOriginId = "LoadBalancer"
+
+var oneDaySeconds int64 = 24 * 60 * 60
+var oneWeekSeconds = 7 * oneDaySeconds
+var oneYearSeconds = 365 * oneDaySeconds
+
+// the static files of WordPress and the uploads, which change rarely and are versioned by a query string
+var staticPathPatterns = []string{"/wp-content/*", "/wp-includes/*"}
+
+// the admin pages and the login page are passed straight through to the sites
+var uncachedPathPatterns = []string{"/wp-admin/*", "/wp-login.php"}
+
+// the cookies of logged in users and commenters, which keep their pages out of the anonymous users' cache entries
+var wordPressCookies = []string{"comment_author_*", "wordpress_*", "wp-postpass_*", "wp-settings-*"}
+
+var allMethods = []string{"GET", "HEAD", "OPTIONS", "PUT", "PATCH", "POST", "DELETE"}
+var cachedMethods = []string{"GET", "HEAD"}
+
+func cloudFrontLogicalName(config *TemplateConfig) string {
+	return config.CfName("CloudFrontDistribution")
+}
+
+// addCloudFrontDistribution serves every host of the sites through CloudFront, which reaches the load balancer over
+// HTTPS. The host header is passed on so that the load balancer can pick the site and its certificate matches.
+func (wprs *WordPressResources) addCloudFrontDistribution(wpSubdomainRsrcs []wpSubdomainResource) {
+	cloudFrontConfig := wprs.config.StageConfig().CloudFront
+
+	var aliases []Stringable
+	for _, wpRsrc := range wpSubdomainRsrcs {
+		for _, hostLabel := range wprs.config.SiteAliasLabels(wpRsrc.site) {
+			aliases = append(aliases, wpRsrc.hostname(hostLabel))
+		}
+	}
+
+	var cacheBehaviors []cf_rsrcs.CacheBehavior
+	for _, pathPattern := range staticPathPatterns {
+		cacheBehavior := staticCacheBehavior()
+		cacheBehavior.PathPattern = String(pathPattern)
+		cacheBehaviors = append(cacheBehaviors, *cacheBehavior)
+	}
+	for _, pathPattern := range uncachedPathPatterns {
+		cacheBehavior := uncachedCacheBehavior()
+		cacheBehavior.PathPattern = String(pathPattern)
+		cacheBehaviors = append(cacheBehaviors, *cacheBehavior)
+	}
+
+	wprs.template.AddResource(
+		cloudFrontLogicalName(wprs.config),
+		&cf_rsrcs.Distribution{
+			DistributionConfig: &cf_rsrcs.DistributionConfig{
+				Aliases:              StringList(aliases...),
+				CacheBehaviors:       cacheBehaviors,
+				Comment:              String(wprs.config.CfName("WordPress")),
+				DefaultCacheBehavior: pageCacheBehavior(),
+				Enabled:              Bool(true),
+				HttpVersion:          String("http2"),
+				IPV6Enabled:          Bool(wprs.config.StageConfig().DualStack),
+				Origins: []cf_rsrcs.DistributionOrigin{
+					{
+						CustomOriginConfig: &cf_rsrcs.CustomOriginConfig{
+							HTTPPort:             Integer(HttpPort),
+							HTTPSPort:            Integer(HttpsPort),
+							OriginProtocolPolicy: String("https-only"),
+							OriginSSLProtocols:   StringList(String("TLSv1.2")),
+						},
+						DomainName: GetAtt(wprs.elbLogicalName, "DNSName"),
+						Id:         String(loadBalancerOriginId),
+					},
+				},
+				PriceClass: String(cloudFrontConfig.Prices()),
+				ViewerCertificate: &cf_rsrcs.ViewerCertificate{
+					AcmCertificateArn:      String(cloudFrontConfig.CertificateArn),
+					MinimumProtocolVersion: String("TLSv1.2_2018"),
+					SslSupportMethod:       String("sni-only"),
+				},
+			},
+			Tags: wprs.config.ResourceTags(),
+		},
+	)
+}
+
+// pageCacheBehavior caches the pages for anonymous users for as long as the sites allow, up to a day. Logged in users
+// and commenters carry cookies that are part of the cache key, so they get pages of their own.
+func pageCacheBehavior() *cf_rsrcs.CacheBehavior {
+	return &cf_rsrcs.CacheBehavior{
+		AllowedMethods: stringList(allMethods),
+		CachedMethods:  stringList(cachedMethods),
+		Compress:       Bool(true),
+		DefaultTTL:     Integer(0),
+		ForwardedValues: &cf_rsrcs.ForwardedValues{
+			Cookies: &cf_rsrcs.ForwardedCookies{
+				Forward:          String("whitelist"),
+				WhitelistedNames: stringList(wordPressCookies),
+			},
+			Headers:     StringList(String("Host")),
+			QueryString: Bool(true),
+		},
+		MaxTTL:               Integer(oneDaySeconds),
+		MinTTL:               Integer(0),
+		TargetOriginId:       String(loadBalancerOriginId),
+		ViewerProtocolPolicy: String("redirect-to-https"),
+	}
+}
+
+// staticCacheBehavior caches the static files for at least a day, whoever asks for them.
+func staticCacheBehavior() *cf_rsrcs.CacheBehavior {
+	return &cf_rsrcs.CacheBehavior{
+		AllowedMethods: stringList(cachedMethods),
+		CachedMethods:  stringList(cachedMethods),
+		Compress:       Bool(true),
+		DefaultTTL:     Integer(oneWeekSeconds),
+		ForwardedValues: &cf_rsrcs.ForwardedValues{
+			Cookies:     &cf_rsrcs.ForwardedCookies{Forward: String("none")},
+			Headers:     StringList(String("Host")),
+			QueryString: Bool(true),
+		},
+		MaxTTL:               Integer(oneYearSeconds),
+		MinTTL:               Integer(oneDaySeconds),
+		TargetOriginId:       String(loadBalancerOriginId),
+		ViewerProtocolPolicy: String("redirect-to-https"),
+	}
+}
+
+// uncachedCacheBehavior passes every header, cookie and query string on, which keeps CloudFront from caching at all.
+func uncachedCacheBehavior() *cf_rsrcs.CacheBehavior {
+	return &cf_rsrcs.CacheBehavior{
+		AllowedMethods: stringList(allMethods),
+		CachedMethods:  stringList(cachedMethods),
+		Compress:       Bool(true),
+		DefaultTTL:     Integer(0),
+		ForwardedValues: &cf_rsrcs.ForwardedValues{
+			Cookies:     &cf_rsrcs.ForwardedCookies{Forward: String("all")},
+			Headers:     StringList(String("*")),
+			QueryString: Bool(true),
+		},
+		MaxTTL:               Integer(0),
+		MinTTL:               Integer(0),
+		TargetOriginId:       String(loadBalancerOriginId),
+		ViewerProtocolPolicy: String("redirect-to-https"),
+	}
+}
+
+func stringList(values []string) *StringListExpr {
+	var stringables []Stringable
+	for _, value := range values {
+		stringables = append(stringables, String(value))
+	}
+	return StringList(stringables...)
+}
@@ -0,0 +1,152 @@
+package wp
+
+import (
+	"fmt"
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/constants"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_funcs"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+// EFS access points need at least this platform version
+var fargatePlatformVersion = "1.4.0"
+
+// FargateResources are the parts of the stack the sites' tasks are wired to when they run on Fargate. The tasks get
+// network interfaces of their own in the application subnets, in the security group of the ECS hosts, which already
+// reaches the EFS mount targets.
+type FargateResources struct {
+	EfsLogicalName             string
+	EfsMountTargetLogicalNames []string
+	SubnetRefs                 *StringListExpr
+	AssignPublicIp             bool
+}
+
+func taskExecutionRoleLogicalName(config *TemplateConfig) string {
+	return config.CfName("EcsTaskExecutionRole")
+}
+
+// addTaskExecutionRole lets ECS pull the images and write the logs of the tasks on their behalf.
+func (wprs *WordPressResources) addTaskExecutionRole() {
+	wprs.template.AddResource(
+		taskExecutionRoleLogicalName(wprs.config),
+		&IAMRole{
+			AssumeRolePolicyDocument: `{
+                "Statement":[
+                {
+                  "Effect":"Allow",
+                  "Principal":{
+                    "Service":[
+                      "ecs-tasks.amazonaws.com"
+                    ]
+                  },
+                  "Action":[
+                    "sts:AssumeRole"
+                  ]
+                }
+              ]
+            }`,
+			ManagedPolicyArns: StringList(
+				Sub(String("arn:${AWS::Partition}:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy")),
+			),
+			Path: String("/"),
+		},
+	)
+}
+
+// addTaskSecurityGroupIngress lets the load balancer reach the tasks, which all listen on the HTTP port of their own
+// network interface, so a single rule covers every site.
+func (wprs *WordPressResources) addTaskSecurityGroupIngress() {
+	wprs.template.AddResource(
+		wprs.config.CfName("EC2SecurityGroupIngressFromElbToTasks"),
+		&EC2SecurityGroupIngress{
+			GroupId:               wprs.ec2SecGrpLogName,
+			SourceSecurityGroupId: wprs.elbSecGrpLogName,
+			IpProtocol:            String(TcpProtocol),
+			FromPort:              Integer(HttpPort),
+			ToPort:                Integer(HttpPort),
+		},
+	)
+}
+
+func (wpr *wpSubdomainResource) usesFargate() bool {
+	return wpr.fargate != nil
+}
+
+func (wpr *wpSubdomainResource) efsAccessPointLogicalName(directory string) string {
+	return wpr.subdomainLogicalName(fmt.Sprintf("EfsAccessPoint%s", directory))
+}
+
+// addEfsAccessPoints expose the site's directories on EFS to its tasks. The access points create the directories if
+// they do not exist yet, which the ECS hosts did when they mounted them into the containers.
+func (wpr *wpSubdomainResource) addEfsAccessPoints() {
+	for _, volume := range wpr.efsVolumes() {
+		wpr.template.AddResource(
+			wpr.efsAccessPointLogicalName(volume.directoryName),
+			&cf_rsrcs.AccessPoint{
+				AccessPointTags: wpr.config.SiteResourceTags(wpr.site.Name),
+				FileSystemId:    Ref(wpr.fargate.EfsLogicalName).String(),
+				RootDirectory: &cf_rsrcs.RootDirectory{
+					CreationInfo: &cf_rsrcs.CreationInfo{
+						OwnerGid:    String("0"),
+						OwnerUid:    String("0"),
+						Permissions: String("755"),
+					},
+					Path: String(fmt.Sprintf("/%s/%s", wpr.site.Name, volume.directory)),
+				},
+			},
+		)
+	}
+}
+
+type efsVolume struct {
+	name          string
+	directory     string
+	directoryName string
+}
+
+// efsVolumes are the site's directories on EFS, which are the same on Fargate as on the ECS hosts.
+func (wpr *wpSubdomainResource) efsVolumes() []efsVolume {
+	return []efsVolume{
+		{wpr.dbDockerVolumeName(), "mysql", "MySql"},
+		{wpr.wpContentDockerVolumeName(), "wp-content", "WpContent"},
+	}
+}
+
+func (wpr *wpSubdomainResource) taskVolumes() []cf_rsrcs.TaskVolume {
+	var volumes []cf_rsrcs.TaskVolume
+	for _, volume := range wpr.efsVolumes() {
+		taskVolume := cf_rsrcs.TaskVolume{Name: String(volume.name)}
+		if wpr.usesFargate() {
+			taskVolume.EFSVolumeConfiguration = &cf_rsrcs.EFSVolumeConfiguration{
+				AuthorizationConfig: &cf_rsrcs.EFSAuthorizationConfig{
+					AccessPointId: Ref(wpr.efsAccessPointLogicalName(volume.directoryName)).String(),
+					IAM:           String("DISABLED"),
+				},
+				FilesystemId:      Ref(wpr.fargate.EfsLogicalName).String(),
+				TransitEncryption: String("ENABLED"),
+			}
+		} else {
+			taskVolume.Host = &cf_rsrcs.TaskVolumeHost{
+				SourcePath: String(fmt.Sprintf("/mnt/efs/%s/%s/", wpr.site.Name, volume.directory)),
+			}
+		}
+		volumes = append(volumes, taskVolume)
+	}
+	return volumes
+}
+
+func (wpr *wpSubdomainResource) networkConfiguration() *cf_rsrcs.NetworkConfiguration {
+	assignPublicIp := "DISABLED"
+	if wpr.fargate.AssignPublicIp {
+		assignPublicIp = "ENABLED"
+	}
+
+	return &cf_rsrcs.NetworkConfiguration{
+		AwsvpcConfiguration: &cf_rsrcs.AwsvpcConfiguration{
+			AssignPublicIp: String(assignPublicIp),
+			SecurityGroups: StringList(wpr.ec2SecGrpRef),
+			Subnets:        wpr.fargate.SubnetRefs,
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package wp
+
+import (
+	"fmt"
+	"strings"
+	. "github.com/crewjam/go-cloudformation"
+	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
+)
+
+func (wpr *wpSubdomainResource) configuredRedirectRuleLogicalName(index int) string {
+	return wpr.config.CfName(fmt.Sprintf("HttpsConfiguredRedirectRule%s%d", wpr.site.LogicalId, index))
+}
+
+func (wpr *wpSubdomainResource) configuredRedirectAliasLogicalName(index int, recordType string) string {
+	return wpr.config.CfName(fmt.Sprintf("RedirectAliasRecord%s%d%s", wpr.site.LogicalId, index, recordType))
+}
+
+func (wpr *wpSubdomainResource) configuredRedirectCertificateLogicalName(index int) string {
+	return wpr.config.CfName(fmt.Sprintf("ElbListenerCertificate%sRedirect%d", wpr.site.LogicalId, index))
+}
+
+// addConfiguredRedirectRules adds a listener rule for each of the site's redirects. The host of a redirect gets alias
+// records from the first of its redirects with a hosted zone, and its certificate is added to the listener from the
+// first with a certificate, so that redirects sharing a host with different paths do not repeat them.
+func (wpr *wpSubdomainResource) addConfiguredRedirectRules() {
+	hostsWithRecords := map[string]bool{}
+	hostsWithCertificates := map[string]bool{}
+	for index, redirect := range wpr.config.SiteConfig(wpr.site).Redirects {
+		wpr.addConfiguredRedirectRule(index, redirect)
+
+		host := strings.ToLower(redirect.FromHost)
+		if redirect.HostedZoneId != "" && !hostsWithRecords[host] {
+			hostsWithRecords[host] = true
+			wpr.addConfiguredRedirectAliasRecordSets(index, redirect)
+		}
+		if redirect.CertificateArn != "" && !hostsWithCertificates[host] {
+			hostsWithCertificates[host] = true
+			wpr.template.AddResource(
+				wpr.configuredRedirectCertificateLogicalName(index),
+				&cf_rsrcs.ListenerCertificate{
+					Certificates: []cf_rsrcs.ListenerCertificateArn{{CertificateArn: String(redirect.CertificateArn)}},
+					ListenerArn:  Ref(wpr.elbListenerLogicalName).String(),
+				},
+			)
+		}
+	}
+}
+
+func (wpr *wpSubdomainResource) addConfiguredRedirectRule(index int, redirect RedirectRuleConfig) {
+	conditions := []cf_rsrcs.ListenerRuleCondition{
+		{
+			Field:            String("host-header"),
+			HostHeaderConfig: &cf_rsrcs.HostHeaderConfig{Values: StringList(String(redirect.FromHost))},
+		},
+	}
+	if redirect.FromPath != "" {
+		conditions = append(conditions, cf_rsrcs.ListenerRuleCondition{
+			Field:  String("path-pattern"),
+			Values: StringList(String(redirect.FromPath)),
+		})
+	}
+
+	redirectConfig := &cf_rsrcs.RedirectConfig{StatusCode: String(redirect.RedirectStatusCode())}
+	if redirect.ToHost != "" {
+		redirectConfig.Host = String(redirect.ToHost)
+	}
+	if redirect.ToPath != "" {
+		redirectConfig.Path = String(redirect.ToPath)
+	}
+
+	wpr.template.AddResource(
+		wpr.configuredRedirectRuleLogicalName(index),
+		&cf_rsrcs.ListenerRule{
+			ElasticLoadBalancingV2ListenerRule: &ElasticLoadBalancingV2ListenerRule{
+				ListenerArn: Ref(wpr.elbListenerLogicalName).String(),
+				Priority:    Integer(wpr.config.SiteConfiguredRedirectRulePriority(wpr.site, index)),
+			},
+			Actions:    []cf_rsrcs.ListenerAction{{RedirectConfig: redirectConfig, Type: String("redirect")}},
+			Conditions: conditions,
+		},
+	)
+}
+
+// addConfiguredRedirectAliasRecordSets points the host of the redirect at the load balancer itself, even when
+// CloudFront is in front of it for the sites, as the distribution only answers for the hosts of the sites.
+func (wpr *wpSubdomainResource) addConfiguredRedirectAliasRecordSets(index int, redirect RedirectRuleConfig) {
+	recordTypes := []string{"A"}
+	if wpr.config.StageConfig().DualStack {
+		recordTypes = append(recordTypes, "AAAA")
+	}
+
+	for _, recordType := range recordTypes {
+		wpr.template.AddResource(
+			wpr.configuredRedirectAliasLogicalName(index, recordType),
+			&Route53RecordSet{
+				AliasTarget:  wpr.elbAliasTarget(),
+				HostedZoneId: String(BareHostedZoneId(redirect.HostedZoneId)),
+				Name:         String(redirect.FromHost),
+				Type:         String(recordType),
+			},
+		)
+	}
+}
@@ -1,42 +1,51 @@
 package wp
 
 import (
+	"fmt"
 	. "github.com/crewjam/go-cloudformation"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/constants"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
 )
 
-var oneCpu int64 = 1024             // in ECS, there are 1024 units per VCPU
-var memoryPerInstanceMb int64 = 992 // based on EC2 t2.micro
-var baseWordPressPort int64 = 9000
-
 type WordPressResources struct {
-	template            *Template
-	config              *TemplateConfig
-	elbLogicalName      string
-	wordPressSubDomains []string
-	vpcIdRefFunc        RefFunc
-	ec2SecGrpLogName    *StringExpr
-	elbSecGrpLogName    *StringExpr
+	template         *Template
+	config           *TemplateConfig
+	elbLogicalName   string
+	wordPressSites   []*Site
+	vpcIdRefFunc     RefFunc
+	ec2SecGrpLogName *StringExpr
+	elbSecGrpLogName *StringExpr
+	// siteCertArns are the certificates of the sites with domains of their own, keyed by the site name
+	siteCertArns map[string]string
+	// fargate is what the tasks are wired to when they run on Fargate, and nil when they run on the ECS hosts
+	fargate *FargateResources
 }
 
 func NewWordPressResources(
-	template *Template, config *TemplateConfig, elbLogicalName string, wordPressSubDomains []string,
-	vpcIdRefFunc RefFunc, ec2SecGrpLogName *StringExpr, elbSecGrpLogName *StringExpr,
+	template *Template, config *TemplateConfig, elbLogicalName string, wordPressSites []*Site,
+	vpcIdRefFunc RefFunc, ec2SecGrpLogName *StringExpr, elbSecGrpLogName *StringExpr, siteCertArns map[string]string,
+	fargate *FargateResources,
 ) WordPressResources {
 	return WordPressResources{
-		template, config, elbLogicalName, wordPressSubDomains, vpcIdRefFunc, ec2SecGrpLogName, elbSecGrpLogName,
+		template, config, elbLogicalName, wordPressSites, vpcIdRefFunc, ec2SecGrpLogName, elbSecGrpLogName,
+		siteCertArns, fargate,
 	}
 }
 
 func (wprs *WordPressResources) AddToTemplate() {
+	containerSize, err := wprs.config.ContainerSize(len(wprs.wordPressSites))
+	if err != nil {
+		panic(err)
+	}
+
 	var wpSubdomainRsrcs []wpSubdomainResource
-	for index, subdomain := range wprs.wordPressSubDomains {
+	for _, site := range wprs.wordPressSites {
 		wpRsrc := newWordPressResource(
 			wprs.template, wprs.config, wprs.elbLogicalName, wprs.elbListenerLogicalName(),
 			wprs.vpcIdRefFunc, wprs.ec2SecGrpLogName, wprs.elbSecGrpLogName, wprs.ecsClusterRef(),
 			Ref(wprs.logGroupLogicalName()).String(),
-			subdomain, baseWordPressPort+int64(index), wprs.cpuUnitsPerTask(), wprs.memoryPerTask(),
+			site, wprs.config.SitePort(site), containerSize.CpuUnits, containerSize.MemoryMb, wprs.fargate,
 		)
 		wpRsrc.AddToTemplate()
 		wpSubdomainRsrcs = append(wpSubdomainRsrcs, wpRsrc)
@@ -44,8 +53,18 @@ func (wprs *WordPressResources) AddToTemplate() {
 
 	wprs.addEcsCluster()
 	wprs.addLogGroup()
+	if wprs.fargate != nil {
+		wprs.addTaskExecutionRole()
+		wprs.addTaskSecurityGroupIngress()
+	}
+
+	wprs.addElbListener()
+	wprs.addElbListenerCertificates()
+	wprs.addElbHttpRedirectListener()
 
-	wprs.addElbListener(wpSubdomainRsrcs[0].elbTargetGroupRef())
+	if wprs.config.StageConfig().CloudFront != nil {
+		wprs.addCloudFrontDistribution(wpSubdomainRsrcs)
+	}
 }
 
 func (wprs *WordPressResources) EcsClusterLogicalName() string {
@@ -64,12 +83,8 @@ func (wprs *WordPressResources) elbListenerLogicalName() string {
 	return wprs.config.CfName("ElbHttpsListener")
 }
 
-func (wprs *WordPressResources) cpuUnitsPerTask() int64 {
-	return oneCpu / int64(2*len(wprs.wordPressSubDomains))
-}
-
-func (wprs *WordPressResources) memoryPerTask() int64 {
-	return memoryPerInstanceMb / int64(2*len(wprs.wordPressSubDomains))
+func (wprs *WordPressResources) elbHttpListenerLogicalName() string {
+	return wprs.config.CfName("ElbHttpListener")
 }
 
 func (wprs *WordPressResources) addEcsCluster() {
@@ -82,31 +97,78 @@ func (wprs *WordPressResources) addEcsCluster() {
 func (wprs *WordPressResources) addLogGroup() {
 	wprs.template.AddResource(
 		wprs.logGroupLogicalName(),
-		&LogsLogGroup{
-			LogGroupName:    Join("-", Ref("AWS::StackName"), String("WordPress"), wprs.config.Stage.StringExpr()),
-			RetentionInDays: Integer(7),
+		&cf_rsrcs.LogGroup{
+			LogsLogGroup: &LogsLogGroup{
+				LogGroupName:    Join("-", Ref("AWS::StackName"), String("WordPress"), wprs.config.Stage.StringExpr()),
+				RetentionInDays: Integer(7),
+			},
+			Tags: wprs.config.ResourceTags(),
 		},
 	)
 }
 
-func (wprs *WordPressResources) addElbListener(defaultTargetGrpArn *StringExpr) {
+func (wprs *WordPressResources) addElbListener() {
 	wprs.template.AddResource(
 		wprs.elbListenerLogicalName(),
-		ElasticLoadBalancingV2Listener{
-			Certificates: &ElasticLoadBalancingListenerCertificatesList{
-				ElasticLoadBalancingListenerCertificates{
-					CertificateArn: Ref(CertificateArnParamName).String(),
+		&cf_rsrcs.Listener{
+			ElasticLoadBalancingV2Listener: &ElasticLoadBalancingV2Listener{
+				Certificates: &ElasticLoadBalancingListenerCertificatesList{
+					ElasticLoadBalancingListenerCertificates{
+						CertificateArn: Ref(CertificateArnParamName).String(),
+					},
 				},
+				LoadBalancerArn: Ref(wprs.elbLogicalName).String(),
+				Port:            Integer(HttpsPort),
+				Protocol:        String(HttpsProtocol),
+				SslPolicy:       String(wprs.config.LoadBalancerSettings().SslPolicy),
+			},
+			DefaultActions: wprs.elbListenerDefaultActions(),
+		},
+	)
+}
+
+// addElbListenerCertificates adds the certificate of each site with a domain of its own to the HTTPS listener, which
+// serves it to clients asking for that domain.
+func (wprs *WordPressResources) addElbListenerCertificates() {
+	for _, site := range wprs.wordPressSites {
+		certArn, exists := wprs.siteCertArns[site.Name]
+		if !exists {
+			continue
+		}
+
+		wprs.template.AddResource(
+			wprs.config.CfName(fmt.Sprintf("ElbListenerCertificate%s", site.LogicalId)),
+			&cf_rsrcs.ListenerCertificate{
+				Certificates: []cf_rsrcs.ListenerCertificateArn{{CertificateArn: String(certArn)}},
+				ListenerArn:  Ref(wprs.elbListenerLogicalName()).String(),
+			},
+		)
+	}
+}
+
+// addElbHttpRedirectListener permanently redirects plain HTTP requests to the same URL over HTTPS.
+func (wprs *WordPressResources) addElbHttpRedirectListener() {
+	wprs.template.AddResource(
+		wprs.elbHttpListenerLogicalName(),
+		&cf_rsrcs.Listener{
+			ElasticLoadBalancingV2Listener: &ElasticLoadBalancingV2Listener{
+				LoadBalancerArn: Ref(wprs.elbLogicalName).String(),
+				Port:            Integer(HttpPort),
+				Protocol:        String(HttpProtocol),
 			},
-			DefaultActions: &ElasticLoadBalancingListenerDefaultActionsList{
-				ElasticLoadBalancingListenerDefaultActions{
-					TargetGroupArn: defaultTargetGrpArn,
-					Type:           String("forward"),
+			DefaultActions: []cf_rsrcs.ListenerAction{
+				{
+					RedirectConfig: &cf_rsrcs.RedirectConfig{
+						Host:       String("#{host}"),
+						Path:       String("/#{path}"),
+						Port:       String(fmt.Sprint(HttpsPort)),
+						Protocol:   String(HttpsProtocol),
+						Query:      String("#{query}"),
+						StatusCode: String("HTTP_301"),
+					},
+					Type: String("redirect"),
 				},
 			},
-			LoadBalancerArn: Ref(wprs.elbLogicalName).String(),
-			Port:            Integer(HttpsPort),
-			Protocol:        String(HttpsProtocol),
 		},
 	)
 }
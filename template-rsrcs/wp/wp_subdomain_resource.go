@@ -7,6 +7,7 @@ import (
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/models"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/constants"
 	. "github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_funcs"
+	"github.com/ErrorsAndGlitches/wordpress-cloud-formation/template-rsrcs/cf_rsrcs"
 )
 
 /**
@@ -24,35 +25,64 @@ type wpSubdomainResource struct {
 	elbSecGrpRef           *StringExpr
 	ecsClusterRef          *StringExpr
 	logGroupRef            *StringExpr
-	subdomain              string
+	site                   *Site
 	port                   int64
 	cpuUnits               int64
 	memoryMb               int64
+	fargate                *FargateResources
 }
 
 func newWordPressResource(
 	template *Template, config *TemplateConfig, elbLogicalName string, elbLstnrLogName string, vpcIdRefFunc RefFunc,
 	ec2SecGrpLogName *StringExpr, elbSecGrpLogName *StringExpr, ecsClusterRef *StringExpr, logGroupRef *StringExpr,
-	subdomain string, port int64, cpuUnits int64, memoryMb int64,
+	site *Site, port int64, cpuUnits int64, memoryMb int64, fargate *FargateResources,
 ) wpSubdomainResource {
 	return wpSubdomainResource{
 		template, config, elbLogicalName, elbLstnrLogName,
 		vpcIdRefFunc, ec2SecGrpLogName, elbSecGrpLogName, ecsClusterRef, logGroupRef,
-		subdomain, port, cpuUnits, memoryMb,
+		site, port, cpuUnits, memoryMb, fargate,
 	}
 }
 
 func (wpr *wpSubdomainResource) AddToTemplate() {
 	wpr.addLoadBalancerTargetGroup()
 	wpr.addElbListenerRules()
-	wpr.addEc2SecurityGroupIngresses()
+	wpr.addElbMaintenanceRule()
+	if redirect := wpr.config.SiteRedirect(wpr.site); redirect != nil {
+		wpr.addElbCanonicalHostRedirectRule(redirect)
+	}
+	wpr.addConfiguredRedirectRules()
+	wpr.addAliasRecordSets()
+	if wpr.usesFargate() {
+		wpr.addEfsAccessPoints()
+	} else {
+		wpr.addEc2SecurityGroupIngresses()
+		wpr.addWpEcsServiceRole()
+	}
 	wpr.addWpEcsService()
-	wpr.addWpEcsServiceRole()
 	wpr.addWpTaskDef()
 }
 
 func (wpr *wpSubdomainResource) elbListenerRuleLogicalName() string {
-	return wpr.config.CfName(fmt.Sprintf("HttpsListenerRule%s", wpr.subdomain))
+	return wpr.config.CfName(fmt.Sprintf("HttpsListenerRule%s", wpr.site.LogicalId))
+}
+
+func (wpr *wpSubdomainResource) elbMaintenanceRuleLogicalName() string {
+	return wpr.config.CfName(fmt.Sprintf("HttpsMaintenanceRule%s", wpr.site.LogicalId))
+}
+
+func (wpr *wpSubdomainResource) maintenanceConditionName() string {
+	return fmt.Sprintf("%sMaintenanceOn", wpr.site.LogicalId)
+}
+
+func (wpr *wpSubdomainResource) elbRedirectRuleLogicalName() string {
+	return wpr.config.CfName(fmt.Sprintf("HttpsRedirectRule%s", wpr.site.LogicalId))
+}
+
+func (wpr *wpSubdomainResource) aliasRecordLogicalName(hostLabel string, recordType string) string {
+	return wpr.config.CfName(
+		fmt.Sprintf("AliasRecord%s%s%s", wpr.site.LogicalId, HostLabelName(wpr.site, hostLabel), recordType),
+	)
 }
 
 func (wpr *wpSubdomainResource) elbTargetGroupLogicalName() string {
@@ -76,68 +106,257 @@ func (wpr *wpSubdomainResource) wpServiceRoleLogicalName() string {
 }
 
 func (wpr *wpSubdomainResource) subdomainLogicalName(basename string) string {
-	return wpr.config.CfName(fmt.Sprintf("%s%s", basename, wpr.subdomain))
+	return wpr.config.CfName(fmt.Sprintf("%s%s", basename, wpr.site.LogicalId))
 }
 
 func (wpr *wpSubdomainResource) dbDockerVolumeName() string {
-	return wpr.config.CfName(fmt.Sprintf("MySqlVolume%s", wpr.subdomain))
+	return wpr.config.CfName(fmt.Sprintf("MySqlVolume%s", wpr.site.LogicalId))
 }
 
 func (wpr *wpSubdomainResource) wpContentDockerVolumeName() string {
-	return wpr.config.CfName(fmt.Sprintf("WpContentVolume%s", wpr.subdomain))
+	return wpr.config.CfName(fmt.Sprintf("WpContentVolume%s", wpr.site.LogicalId))
 }
 
 func (wpr *wpSubdomainResource) dbContainerName() string {
-	return wpr.config.CfName(fmt.Sprintf("MariaDbContainer%s", wpr.subdomain))
+	return wpr.config.CfName(fmt.Sprintf("MariaDbContainer%s", wpr.site.LogicalId))
 }
 
+// addLoadBalancerTargetGroup registers the tasks by the host port of the site on the ECS hosts, or by the IP of their
+// own network interface on Fargate.
 func (wpr *wpSubdomainResource) addLoadBalancerTargetGroup() {
-	targetGroup := Resource{
-		DependsOn: []string{wpr.elbLogicalName},
-		Properties: &ElasticLoadBalancingV2TargetGroup{
-			HealthCheckIntervalSeconds: Integer(10),
-			HealthCheckPath:            String("/"),
-			HealthCheckPort:            String(strconv.FormatInt(wpr.port, 10)),
+	targetGroupConfig := wpr.config.SiteTargetGroup(wpr.site)
+
+	targetGroup := &cf_rsrcs.TargetGroup{
+		ElasticLoadBalancingV2TargetGroup: &ElasticLoadBalancingV2TargetGroup{
+			HealthCheckIntervalSeconds: Integer(int64(targetGroupConfig.HealthCheckIntervalSeconds)),
+			HealthCheckPath:            String(targetGroupConfig.HealthCheckPath),
+			HealthCheckPort:            String(strconv.FormatInt(wpr.targetPort(), 10)),
 			HealthCheckProtocol:        String(HttpProtocol),
-			HealthCheckTimeoutSeconds:  Integer(5),
-			HealthyThresholdCount:      Integer(2),
+			HealthCheckTimeoutSeconds:  Integer(int64(targetGroupConfig.HealthCheckTimeoutSeconds)),
+			HealthyThresholdCount:      Integer(int64(targetGroupConfig.HealthyThreshold)),
 			Matcher: &ElasticLoadBalancingTargetGroupMatcher{
-				HttpCode: String("200,301,302"),
+				HttpCode: String(targetGroupConfig.HealthCheckMatcher),
 			},
-			Port:                       Integer(wpr.port),
-			Protocol:                   String(HttpProtocol),
-			UnhealthyThresholdCount:    Integer(2),
-			VpcId:                      wpr.vpcIdRefFunc.String(),
+			Port:                    Integer(wpr.targetPort()),
+			Protocol:                String(HttpProtocol),
+			Tags:                    wpr.config.SiteResourceTags(wpr.site.Name),
+			TargetGroupAttributes:   targetGroupAttributes(targetGroupConfig),
+			UnhealthyThresholdCount: Integer(int64(targetGroupConfig.UnhealthyThreshold)),
+			VpcId:                   wpr.vpcIdRefFunc.String(),
+		},
+	}
+	if wpr.usesFargate() {
+		targetGroup.TargetType = String("ip")
+	}
+
+	wpr.template.Resources[wpr.elbTargetGroupLogicalName()] = &Resource{
+		DependsOn:  []string{wpr.elbLogicalName},
+		Properties: targetGroup,
+	}
+}
+
+// targetPort is the port the load balancer reaches the site's task on.
+func (wpr *wpSubdomainResource) targetPort() int64 {
+	if wpr.usesFargate() {
+		return HttpPort
+	}
+	return wpr.port
+}
+
+// targetGroupAttributes always give the deregistration delay, while slow start and stickiness are only given if they
+// are turned on.
+func targetGroupAttributes(
+	targetGroupConfig *TargetGroupConfig,
+) *ElasticLoadBalancingTargetGroupTargetGroupAttributesList {
+	attributes := ElasticLoadBalancingTargetGroupTargetGroupAttributesList{
+		{
+			Key:   String("deregistration_delay.timeout_seconds"),
+			Value: String(strconv.Itoa(targetGroupConfig.DeregistrationDelaySeconds)),
 		},
 	}
 
-	wpr.template.Resources[wpr.elbTargetGroupLogicalName()] = &targetGroup
+	if targetGroupConfig.SlowStartSeconds != 0 {
+		attributes = append(attributes, ElasticLoadBalancingTargetGroupTargetGroupAttributes{
+			Key:   String("slow_start.duration_seconds"),
+			Value: String(strconv.Itoa(targetGroupConfig.SlowStartSeconds)),
+		})
+	}
+
+	if targetGroupConfig.StickinessSeconds != 0 {
+		attributes = append(
+			attributes,
+			ElasticLoadBalancingTargetGroupTargetGroupAttributes{
+				Key:   String("stickiness.enabled"),
+				Value: String("true"),
+			},
+			ElasticLoadBalancingTargetGroupTargetGroupAttributes{
+				Key:   String("stickiness.type"),
+				Value: String("lb_cookie"),
+			},
+			ElasticLoadBalancingTargetGroupTargetGroupAttributes{
+				Key:   String("stickiness.lb_cookie.duration_seconds"),
+				Value: String(strconv.Itoa(targetGroupConfig.StickinessSeconds)),
+			},
+		)
+	}
+
+	return &attributes
 }
 
 func (wpr *wpSubdomainResource) addElbListenerRules() {
 	wpr.template.AddResource(
 		wpr.elbListenerRuleLogicalName(),
-		ElasticLoadBalancingV2ListenerRule{
-			Actions: &ElasticLoadBalancingListenerRuleActionsList{
-				ElasticLoadBalancingListenerRuleActions{
-					TargetGroupArn: wpr.elbTargetGroupRef(),
-					Type:           String("forward"),
+		&cf_rsrcs.ListenerRule{
+			ElasticLoadBalancingV2ListenerRule: &ElasticLoadBalancingV2ListenerRule{
+				ListenerArn: Ref(wpr.elbListenerLogicalName).String(),
+				Priority:    Integer(wpr.config.SiteRulePriority(wpr.site)),
+			},
+			Actions:    wpr.forwardActions(),
+			Conditions: wpr.forwardConditions(),
+		},
+	)
+}
+
+// addElbMaintenanceRule answers every request for the site with a 503 and the maintenance page while the site's
+// maintenance parameter is 'on'. The rule is checked before the forward rule and only exists while it is needed, so
+// the site is untouched otherwise. The parameter defaults to 'off' and keeps its value across stack updates.
+func (wpr *wpSubdomainResource) addElbMaintenanceRule() {
+	paramName := MaintenanceParamName(wpr.site)
+	wpr.template.Parameters[paramName] = &Parameter{
+		AllowedValues: MaintenanceModes,
+		Default:       MaintenanceOff,
+		Description:   fmt.Sprintf("Whether the WordPress site '%s' is in maintenance", wpr.site.Name),
+		Type:          "String",
+	}
+
+	if wpr.template.Conditions == nil {
+		wpr.template.Conditions = map[string]interface{}{}
+	}
+	wpr.template.Conditions[wpr.maintenanceConditionName()] = Equals(Ref(paramName), String(MaintenanceOn))
+
+	wpr.template.Resources[wpr.elbMaintenanceRuleLogicalName()] = &Resource{
+		Condition: wpr.maintenanceConditionName(),
+		Properties: &cf_rsrcs.ListenerRule{
+			ElasticLoadBalancingV2ListenerRule: &ElasticLoadBalancingV2ListenerRule{
+				ListenerArn: Ref(wpr.elbListenerLogicalName).String(),
+				Priority:    Integer(wpr.config.SiteMaintenanceRulePriority(wpr.site)),
+			},
+			Actions: []cf_rsrcs.ListenerAction{
+				{
+					FixedResponseConfig: &cf_rsrcs.FixedResponseConfig{
+						ContentType: String("text/html"),
+						MessageBody: String(wpr.config.SiteMaintenanceBody(wpr.site)),
+						StatusCode:  String("503"),
+					},
+					Type: String("fixed-response"),
 				},
 			},
-			Conditions: &ElasticLoadBalancingListenerRuleConditionsList{
-				ElasticLoadBalancingListenerRuleConditions{
-					Field: String("host-header"),
-					Values: StringList(
-						Sub(String(fmt.Sprintf("%s.${%s}", wpr.subdomain, DomainNameParamName))),
-					),
+			Conditions: []cf_rsrcs.ListenerRuleCondition{
+				wpr.hostHeaderCondition(wpr.config.SiteHostLabels(wpr.site)...),
+			},
+		},
+	}
+}
+
+// addElbCanonicalHostRedirectRule permanently redirects requests for the apex to www, or the other way around,
+// keeping the path and query.
+func (wpr *wpSubdomainResource) addElbCanonicalHostRedirectRule(redirect *SiteRedirect) {
+	wpr.template.AddResource(
+		wpr.elbRedirectRuleLogicalName(),
+		&cf_rsrcs.ListenerRule{
+			ElasticLoadBalancingV2ListenerRule: &ElasticLoadBalancingV2ListenerRule{
+				ListenerArn: Ref(wpr.elbListenerLogicalName).String(),
+				Priority:    Integer(wpr.config.SiteRedirectRulePriority(wpr.site)),
+			},
+			Actions: []cf_rsrcs.ListenerAction{
+				{
+					RedirectConfig: &cf_rsrcs.RedirectConfig{
+						Host:       wpr.hostname(redirect.ToLabel),
+						StatusCode: String("HTTP_301"),
+					},
+					Type: String("redirect"),
 				},
 			},
-			ListenerArn: Ref(wpr.elbListenerLogicalName).String(),
-			Priority:    Integer(int64(wpr.port)),
+			Conditions: []cf_rsrcs.ListenerRuleCondition{wpr.hostHeaderCondition(redirect.FromLabel)},
 		},
 	)
 }
 
+// addAliasRecordSets points every host of the site at the load balancer, or the CloudFront distribution in front of it,
+// with AAAA records next to the A records if the stage is dual stack. The records live in the hosted zone of the site's
+// own domain or else the hosted zone parameter, and go away with the site.
+func (wpr *wpSubdomainResource) addAliasRecordSets() {
+	recordTypes := []string{"A"}
+	if wpr.config.StageConfig().DualStack {
+		recordTypes = append(recordTypes, "AAAA")
+	}
+
+	for _, hostLabel := range wpr.config.SiteAliasLabels(wpr.site) {
+		for _, recordType := range recordTypes {
+			wpr.template.AddResource(
+				wpr.aliasRecordLogicalName(hostLabel, recordType),
+				&Route53RecordSet{
+					AliasTarget:  wpr.aliasTarget(),
+					HostedZoneId: wpr.hostedZoneId(),
+					Name:         wpr.hostname(hostLabel),
+					Type:         String(recordType),
+				},
+			)
+		}
+	}
+}
+
+func (wpr *wpSubdomainResource) aliasTarget() *Route53AliasTargetProperty {
+	if wpr.config.StageConfig().CloudFront != nil {
+		return &Route53AliasTargetProperty{
+			DNSName:      GetAtt(cloudFrontLogicalName(wpr.config), "DomainName"),
+			HostedZoneId: String(cf_rsrcs.CloudFrontHostedZoneId),
+		}
+	}
+
+	return wpr.elbAliasTarget()
+}
+
+func (wpr *wpSubdomainResource) elbAliasTarget() *Route53AliasTargetProperty {
+	return &Route53AliasTargetProperty{
+		DNSName:      Join("", String("dualstack."), GetAtt(wpr.elbLogicalName, "DNSName")),
+		HostedZoneId: GetAtt(wpr.elbLogicalName, "CanonicalHostedZoneID"),
+	}
+}
+
+func (wpr *wpSubdomainResource) hostedZoneId() *StringExpr {
+	if siteConfig := wpr.config.SiteConfig(wpr.site); siteConfig.HasOwnDomain() {
+		return String(BareHostedZoneId(siteConfig.HostedZoneId))
+	}
+
+	return Ref(HostedZoneIdParamName).String()
+}
+
+func (wpr *wpSubdomainResource) hostHeaderCondition(hostLabels ...string) cf_rsrcs.ListenerRuleCondition {
+	return cf_rsrcs.ListenerRuleCondition{
+		Field:            String("host-header"),
+		HostHeaderConfig: &cf_rsrcs.HostHeaderConfig{Values: wpr.hostHeaderValues(hostLabels...)},
+	}
+}
+
+func (wpr *wpSubdomainResource) hostHeaderValues(hostLabels ...string) *StringListExpr {
+	var hostnames []Stringable
+	for _, hostLabel := range hostLabels {
+		hostnames = append(hostnames, wpr.hostname(hostLabel))
+	}
+
+	return StringList(hostnames...)
+}
+
+// hostname is the full host name of a host label, under the site's own domain or else the domain name parameter.
+func (wpr *wpSubdomainResource) hostname(hostLabel string) *StringExpr {
+	if siteConfig := wpr.config.SiteConfig(wpr.site); siteConfig.HasOwnDomain() {
+		return String(Hostname(hostLabel, siteConfig.DomainName))
+	}
+
+	return Sub(String(Hostname(hostLabel, fmt.Sprintf("${%s}", DomainNameParamName))))
+}
+
 func (wpr *wpSubdomainResource) addEc2SecurityGroupIngresses() {
 	type BaseIdTuple struct {
 		basename       string
@@ -161,12 +380,12 @@ func (wpr *wpSubdomainResource) addEc2SecurityGroupIngresses() {
 	}
 }
 
+// addWpEcsService runs a single task of the site. On Fargate the old task is stopped before the new one starts, as
+// nothing else keeps the two from running a database each on the same data directory, which the fixed host port of
+// the site does on the ECS hosts.
 func (wpr *wpSubdomainResource) addWpEcsService() {
-	wpr.template.Resources[wpr.subdomainLogicalName("WpEcsService")] = &Resource{
-		DependsOn: []string{
-			wpr.elbListenerLogicalName, wpr.elbLogicalName, wpr.elbTargetGroupLogicalName(), wpr.elbListenerRuleLogicalName(),
-		},
-		Properties: &ECSService{
+	service := &cf_rsrcs.Service{
+		ECSService: &ECSService{
 			Cluster:      wpr.ecsClusterRef,
 			DesiredCount: Integer(1),
 			LoadBalancers: &EC2ContainerServiceServiceLoadBalancersList{
@@ -179,6 +398,28 @@ func (wpr *wpSubdomainResource) addWpEcsService() {
 			Role:           Ref(wpr.wpServiceRoleLogicalName()).String(),
 			TaskDefinition: Ref(wpr.wpTaskDefLogicalName()).String(),
 		},
+		PropagateTags: String("SERVICE"),
+		Tags:          wpr.config.SiteResourceTags(wpr.site.Name),
+	}
+	dependsOn := []string{
+		wpr.elbListenerLogicalName, wpr.elbLogicalName, wpr.elbTargetGroupLogicalName(), wpr.elbListenerRuleLogicalName(),
+	}
+
+	if wpr.usesFargate() {
+		service.DeploymentConfiguration = &EC2ContainerServiceServiceDeploymentConfiguration{
+			MaximumPercent:        Integer(100),
+			MinimumHealthyPercent: Integer(0),
+		}
+		service.Role = nil
+		service.LaunchType = String("FARGATE")
+		service.NetworkConfiguration = wpr.networkConfiguration()
+		service.PlatformVersion = String(fargatePlatformVersion)
+		dependsOn = append(dependsOn, wpr.fargate.EfsMountTargetLogicalNames...)
+	}
+
+	wpr.template.Resources[wpr.subdomainLogicalName("WpEcsService")] = &Resource{
+		DependsOn:  dependsOn,
+		Properties: service,
 	}
 }
 
@@ -190,43 +431,36 @@ func (wpr *wpSubdomainResource) addWpEcsServiceRole() {
 }
 
 func (wpr *wpSubdomainResource) addWpTaskDef() {
-	wpr.template.AddResource(
-		wpr.wpTaskDefLogicalName(),
-		&ECSTaskDefinition{
+	taskDef := &cf_rsrcs.TaskDefinition{
+		ECSTaskDefinition: &ECSTaskDefinition{
 			ContainerDefinitions: &EC2ContainerServiceTaskDefinitionContainerDefinitionsList{
 				wpr.wordpressContainerDef(),
 				wpr.databaseContainerDef(),
 			},
-			Volumes: &EC2ContainerServiceTaskDefinitionVolumesList{
-				EC2ContainerServiceTaskDefinitionVolumes{
-					Name: String(wpr.dbDockerVolumeName()),
-					Host: &EC2ContainerServiceTaskDefinitionVolumesHost{
-						SourcePath: String(fmt.Sprintf("/mnt/efs/%s/mysql/", wpr.subdomain)),
-					},
-				},
-				EC2ContainerServiceTaskDefinitionVolumes{
-					Name: String(wpr.wpContentDockerVolumeName()),
-					Host: &EC2ContainerServiceTaskDefinitionVolumesHost{
-						SourcePath: String(fmt.Sprintf("/mnt/efs/%s/wp-content/", wpr.subdomain)),
-					},
-				},
-			},
 		},
-	)
+		Volumes: wpr.taskVolumes(),
+	}
+
+	// Fargate sizes the task as a whole, and the containers split it
+	if wpr.usesFargate() {
+		fargate := wpr.config.FargateSettings()
+		taskDef.Cpu = String(strconv.Itoa(fargate.Cpu))
+		taskDef.ExecutionRoleArn = GetAtt(taskExecutionRoleLogicalName(wpr.config), "Arn")
+		taskDef.Memory = String(strconv.Itoa(fargate.MemoryMb))
+		taskDef.NetworkMode = String("awsvpc")
+		taskDef.RequiresCompatibilities = StringList(String("FARGATE"))
+	}
+
+	wpr.template.AddResource(wpr.wpTaskDefLogicalName(), taskDef)
 }
 
 func (wpr *wpSubdomainResource) wordpressContainerDef() EC2ContainerServiceTaskDefinitionContainerDefinitions {
 	return EC2ContainerServiceTaskDefinitionContainerDefinitions{
-		Cpu: Integer(wpr.cpuUnits),
-		Environment: &EC2ContainerServiceTaskDefinitionContainerDefinitionsEnvironmentList{
-			{
-				Name:  String("WORDPRESS_TABLE_PREFIX"),
-				Value: String(wpr.subdomain),
-			},
-		},
+		Cpu:              Integer(wpr.cpuUnits),
+		Environment:      wpr.wpEnvironment(),
 		Essential:        Bool(true),
-		Image:            String("wordpress"),
-		Links:            StringList(String(fmt.Sprintf("%s:mysql", wpr.dbContainerName()))),
+		Image:            String(wpr.config.ImageSettings().WordPress),
+		Links:            wpr.databaseLinks(),
 		LogConfiguration: wpr.ecsLogConfig(),
 		Memory:           Integer(wpr.memoryMb),
 		MountPoints: &EC2ContainerServiceTaskDefinitionContainerDefinitionsMountPointsList{
@@ -239,13 +473,52 @@ func (wpr *wpSubdomainResource) wordpressContainerDef() EC2ContainerServiceTaskD
 		PortMappings: &EC2ContainerServiceTaskDefinitionContainerDefinitionsPortMappingsList{
 			{
 				ContainerPort: Integer(HttpPort),
-				HostPort:      Integer(wpr.port),
+				HostPort:      Integer(wpr.targetPort()),
 				Protocol:      String(TcpProtocol),
 			},
 		},
 	}
 }
 
+// wpEnvironment points WordPress at the database. Linked to the database container, it finds the host and the
+// password through the link, which awsvpc tasks cannot have, so on Fargate they are given and the host is the task's
+// own network interface the containers share.
+func (wpr *wpSubdomainResource) wpEnvironment() *EC2ContainerServiceTaskDefinitionContainerDefinitionsEnvironmentList {
+	environment := EC2ContainerServiceTaskDefinitionContainerDefinitionsEnvironmentList{
+		{
+			Name:  String("WORDPRESS_TABLE_PREFIX"),
+			Value: String(wpr.site.TablePrefix()),
+		},
+	}
+
+	if wpr.usesFargate() {
+		environment = append(
+			environment,
+			EC2ContainerServiceTaskDefinitionContainerDefinitionsEnvironment{
+				Name:  String("WORDPRESS_DB_HOST"),
+				Value: String("127.0.0.1"),
+			},
+			EC2ContainerServiceTaskDefinitionContainerDefinitionsEnvironment{
+				Name:  String("WORDPRESS_DB_USER"),
+				Value: String("root"),
+			},
+			EC2ContainerServiceTaskDefinitionContainerDefinitionsEnvironment{
+				Name:  String("WORDPRESS_DB_PASSWORD"),
+				Value: Ref(MysqlPasswordParamName).String(),
+			},
+		)
+	}
+
+	return &environment
+}
+
+func (wpr *wpSubdomainResource) databaseLinks() *StringListExpr {
+	if wpr.usesFargate() {
+		return nil
+	}
+	return StringList(String(fmt.Sprintf("%s:mysql", wpr.dbContainerName())))
+}
+
 func (wpr *wpSubdomainResource) databaseContainerDef() EC2ContainerServiceTaskDefinitionContainerDefinitions {
 	return EC2ContainerServiceTaskDefinitionContainerDefinitions{
 		Cpu:       Integer(wpr.cpuUnits),
@@ -257,7 +530,7 @@ func (wpr *wpSubdomainResource) databaseContainerDef() EC2ContainerServiceTaskDe
 			},
 		},
 		Name:             String(wpr.dbContainerName()),
-		Image:            String("mariadb:10.3.2"),
+		Image:            String(wpr.config.ImageSettings().Database),
 		LogConfiguration: wpr.ecsLogConfig(),
 		Memory:           Integer(wpr.memoryMb),
 		MountPoints: &EC2ContainerServiceTaskDefinitionContainerDefinitionsMountPointsList{